@@ -2,28 +2,50 @@ package api
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"runtime"
 	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+
 	"github.com/golang-cz/skeleton/app/api/rest"
 	"github.com/golang-cz/skeleton/app/api/rpc"
 	"github.com/golang-cz/skeleton/config"
 	"github.com/golang-cz/skeleton/data"
+	"github.com/golang-cz/skeleton/data/migration"
 	"github.com/golang-cz/skeleton/internal/reqctx"
+	"github.com/golang-cz/skeleton/pkg/compress"
 	"github.com/golang-cz/skeleton/pkg/events"
+	"github.com/golang-cz/skeleton/pkg/idempotency"
+	"github.com/golang-cz/skeleton/pkg/jwtauth"
+	"github.com/golang-cz/skeleton/pkg/maintenance"
+	"github.com/golang-cz/skeleton/pkg/metrics"
 	"github.com/golang-cz/skeleton/pkg/nats"
+	"github.com/golang-cz/skeleton/pkg/ratelimit"
+	"github.com/golang-cz/skeleton/pkg/rbac"
 	"github.com/golang-cz/skeleton/pkg/slogger"
+	"github.com/golang-cz/skeleton/pkg/static"
 	"github.com/golang-cz/skeleton/pkg/status"
+	"github.com/golang-cz/skeleton/pkg/tlsconfig"
+	"github.com/golang-cz/skeleton/pkg/tracing"
 	"github.com/golang-cz/skeleton/pkg/version"
 	"github.com/golang-cz/skeleton/proto"
+	"github.com/golang-cz/skeleton/web"
 )
 
 type API struct {
 	Config           *config.Config
 	DB               *data.Database
 	HTTP             *http.Server
+	Admin            *http.Server        // Nil unless admin router has its own listener.
+	TLS              *tlsconfig.Reloader // Nil unless TLS is enabled.
 	RPC              *rpc.Rpc
 	REST             *rest.Server
 	shutdownFinished chan struct{}
@@ -36,6 +58,17 @@ func New(ctx context.Context, conf *config.Config) (*API, error) {
 		return nil, fmt.Errorf("failed to connect to main DB: %w", err)
 	}
 
+	schemaStatus, err := migration.Status(ctx, database.Driver().(*sql.DB))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check DB schema: %w", err)
+	}
+	if !schemaStatus.IsCurrent() {
+		if conf.Goose.RequireCurrentSchema {
+			return nil, fmt.Errorf("DB schema is older than expected (%v), run migrations first", schemaStatus)
+		}
+		slog.Warn("DB schema is older than expected", slog.Any("schema", schemaStatus.String()))
+	}
+
 	// NATS
 	if _, err := nats.Connect("api", conf.NATS); err != nil {
 		err = fmt.Errorf("failed to connect to NATS server: %w", err)
@@ -47,29 +80,157 @@ func New(ctx context.Context, conf *config.Config) (*API, error) {
 		slog.Error(slogger.ErrorCause(err).Error())
 	}
 
+	// Metrics
+	if conf.Metrics.Enabled {
+		if err := metrics.RegisterDB("main", database.Driver().(*sql.DB)); err != nil {
+			return nil, fmt.Errorf("failed to register DB metrics: %w", err)
+		}
+		if err := metrics.RegisterNATS(nats.Conn); err != nil {
+			return nil, fmt.Errorf("failed to register NATS metrics: %w", err)
+		}
+	}
+
+	// JWT auth
+	auth, err := jwtauth.NewVerifier(ctx, conf.Auth)
+	if errors.Is(err, jwtauth.ErrNotConfigured) {
+		slog.Warn("JWT auth is not configured, all requests are anonymous")
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to setup JWT auth: %w", err)
+	}
+
+	// Rate limiting
+	var limiter *ratelimit.Limiter
+	if conf.RateLimit.Enabled {
+		limiter, err = ratelimit.New(conf.RateLimit, conf.Redis)
+		if err != nil {
+			return nil, fmt.Errorf("failed to setup rate limiting: %w", err)
+		}
+	}
+
+	// Idempotency keys
+	var idempotencyStore idempotency.Store
+	if conf.Idempotency.Enabled {
+		switch conf.Idempotency.Backend {
+		case "", "postgres":
+			idempotencyStore = database.IdempotencyKey
+		case "redis":
+			idempotencyStore = idempotency.NewRedisStore(conf.Redis)
+		default:
+			return nil, fmt.Errorf("unknown idempotency backend %q", conf.Idempotency.Backend)
+		}
+	}
+
+	// Authorization
+	authorizer := rbac.New(database.Role, conf.RBAC)
+	methodPermissions, err := rpc.MethodPermissions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup authorization: %w", err)
+	}
+
 	rpcServer := &rpc.Rpc{
 		Config: conf,
 		DB:     database,
+		RBAC:   authorizer,
 	}
 
 	rpcHandler := proto.NewSkeletonServer(rpcServer)
 	rpcHandler.OnError = func(r *http.Request, rpcErr *proto.WebRPCError) {
 		ctx := r.Context()
+		rpc.MapError(rpcErr)
 		reqctx.AddAttr(ctx, "webrpcError", rpcErr)
+		metrics.RPCError(ctx, rpcErr.Name)
+		tracing.RecordError(ctx, rpcErr)
 
 		if conf.Environment.IsProduction() {
 			rpcErr.Cause = "" // Hide error details in production.
 		}
+		*rpcErr = rpcErr.WithRequestId(reqctx.GetRequestId(ctx))
+	}
+
+	// Maintenance mode
+	maintenanceMode, err := maintenance.New(ctx, &database.Maintenance, conf.Maintenance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup maintenance mode: %w", err)
+	}
+	maintenanceGuard, err := rest.Maintenance(maintenanceMode, conf.Maintenance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup maintenance mode: %w", err)
+	}
+
+	// Frontend
+	var staticServer *static.Server
+	if conf.Static.Enabled {
+		staticServer, err = static.New(web.Dist(), conf.Static)
+		if err != nil {
+			return nil, fmt.Errorf("failed to setup frontend serving: %w", err)
+		}
+	}
+
+	// Response compression
+	compressor, err := compress.Middleware(conf.HTTP.Compression)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup compression: %w", err)
 	}
 
 	restServer := &rest.Server{
 		Config: conf,
 		DB:     database,
+		Auth:   auth,
+
+		RBAC:              authorizer,
+		MethodPermissions: methodPermissions,
+		ScopePermissions:  rpc.ScopePermissions(),
+
+		RateLimiter:      limiter,
+		IdempotencyStore: idempotencyStore,
+
+		Compress:    compressor,
+		Maintenance: maintenanceGuard,
+
+		MaintenanceMode: maintenanceMode,
+		Static:          staticServer,
+	}
+
+	// Admin router
+	var adminRouter http.Handler
+	var adminSrv *http.Server
+	if conf.Admin.Enabled {
+		adminRouter, err = restServer.AdminRouter()
+		if err != nil {
+			return nil, fmt.Errorf("failed to setup admin router: %w", err)
+		}
+
+		runtime.SetBlockProfileRate(conf.Admin.BlockProfileRate)
+		runtime.SetMutexProfileFraction(conf.Admin.MutexProfileFraction)
+
+		if conf.Admin.BindAddress != "" {
+			r := chi.NewRouter()
+			r.Use(restServer.PeerAddr)
+			r.Use(restServer.SecurityHeaders())
+			r.Use(restServer.AdminCORS)
+			r.Use(restServer.RequestId)
+			r.Use(slogger.SloggerMiddleware(conf))
+			r.Use(compressor)
+			r.Use(middleware.Recoverer)
+			r.Mount("/", adminRouter)
+
+			adminSrv = &http.Server{
+				Addr:              conf.Admin.BindAddress,
+				Handler:           r,
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+			adminRouter = nil
+		}
+	}
+
+	var handler http.Handler = restServer.Router(rpcHandler, adminRouter)
+	if conf.HTTP.H2C && !conf.HTTP.TLS.Enabled {
+		handler = h2c.NewHandler(handler, &http2.Server{IdleTimeout: 60 * time.Second})
 	}
 
 	srv := &http.Server{
 		Addr:              conf.Port,
-		Handler:           restServer.Router(rpcHandler),
+		Handler:           handler,
 		IdleTimeout:       60 * time.Second, // idle connections
 		ReadHeaderTimeout: 10 * time.Second, // request header
 		ReadTimeout:       5 * time.Minute,  // request body
@@ -77,12 +238,31 @@ func New(ctx context.Context, conf *config.Config) (*API, error) {
 		MaxHeaderBytes:    1 << 20,          // 1 MB
 	}
 
+	// TLS with HTTP/2
+	var tlsReloader *tlsconfig.Reloader
+	if conf.HTTP.TLS.Enabled {
+		tlsReloader, err = tlsconfig.New(conf.HTTP.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("failed to setup TLS: %w", err)
+		}
+		srv.TLSConfig = tlsReloader.Config()
+		if err := http2.ConfigureServer(srv, &http2.Server{}); err != nil {
+			return nil, fmt.Errorf("failed to setup HTTP/2: %w", err)
+		}
+	}
+
+	if staticServer != nil {
+		srv.RegisterOnShutdown(staticServer.Close)
+	}
+
 	app := &API{
 		Config: conf,
 		DB:     database,
 		RPC:    rpcServer,
 		REST:   restServer,
 		HTTP:   srv,
+		Admin:  adminSrv,
+		TLS:    tlsReloader,
 
 		shutdownFinished: make(chan struct{}, 1),
 	}
@@ -94,9 +274,28 @@ func (app *API) Run() error {
 	slog.Info(fmt.Sprintf("API serving at %v", app.Config.Port),
 		slog.Any("env", app.Config.Environment.String()),
 		slog.Any("version", version.VERSION),
+		slog.Bool("tls", app.TLS != nil),
 	)
 
-	err := app.HTTP.ListenAndServe()
+	if app.Admin != nil {
+		go func() {
+			slog.Info(fmt.Sprintf("API admin serving at %v", app.Admin.Addr))
+
+			err := app.Admin.ListenAndServe()
+			if err != nil && err != http.ErrServerClosed {
+				err = fmt.Errorf("admin listening and serving: %w", err)
+				slog.Error(slogger.ErrorCause(err).Error())
+			}
+		}()
+	}
+
+	var err error
+	if app.TLS != nil {
+		// Certificates come from app.HTTP.TLSConfig.
+		err = app.HTTP.ListenAndServeTLS("", "")
+	} else {
+		err = app.HTTP.ListenAndServe()
+	}
 	if err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("listening and serving: %w", err)
 	}
@@ -108,6 +307,19 @@ func (app *API) Run() error {
 	return nil
 }
 
+// Drain fails readiness probe for the configured drain period, while
+// requests are still served. Call it before Stop.
+func (app *API) Drain() {
+	period := time.Duration(app.Config.HTTP.DrainPeriod)
+	slog.Info("API: draining..", slog.Any("period", period))
+
+	app.REST.Drain()
+	// Make clients reconnect, likely to another instance.
+	app.HTTP.SetKeepAlivesEnabled(false)
+
+	time.Sleep(period)
+}
+
 func (app *API) Stop(maxDuration time.Duration) (err error) {
 	slog.Info("API: HTTP server gracefully shutting down..", slog.Any("maxDuration", maxDuration))
 
@@ -136,6 +348,12 @@ func (app *API) Stop(maxDuration time.Duration) (err error) {
 	if err != nil {
 		return fmt.Errorf("shutting down HTTP server: %w", err)
 	}
+	if app.Admin != nil {
+		err = app.Admin.Shutdown(ctx)
+		if err != nil {
+			return fmt.Errorf("shutting down admin HTTP server: %w", err)
+		}
+	}
 
 	return nil
 }
@@ -143,6 +361,30 @@ func (app *API) Stop(maxDuration time.Duration) (err error) {
 func (app *API) teardown() {
 	slog.Info("API: tearing down..")
 
+	app.REST.MaintenanceMode.Close()
+
+	if app.TLS != nil {
+		app.TLS.Close()
+	}
+
 	_ = app.DB.Close()
 	nats.Close()
+
+	if app.REST.Auth != nil {
+		app.REST.Auth.Close()
+	}
+
+	if app.REST.RateLimiter != nil {
+		_ = app.REST.RateLimiter.Close()
+	}
+
+	if store, ok := app.REST.IdempotencyStore.(*idempotency.RedisStore); ok {
+		_ = store.Close()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tracing.Shutdown(ctx); err != nil {
+		slog.Error(slogger.ErrorCause(err).Error())
+	}
 }
@@ -0,0 +1,177 @@
+package rest
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/golang-cz/skeleton/internal/reqctx"
+	"github.com/golang-cz/skeleton/pkg/alert"
+	"github.com/golang-cz/skeleton/pkg/metrics"
+	"github.com/golang-cz/skeleton/pkg/slogger"
+	"github.com/golang-cz/skeleton/pkg/version"
+	"github.com/golang-cz/skeleton/pkg/ws"
+)
+
+// AdminRouter serves operational endpoints. Requests must come from an
+// allowed IP and carry the admin credential, see config.Admin.
+//
+//	/debug/pprof/*  profiles
+//	/build-info     version and Go build info
+//	/config         config with secrets redacted
+//	/log-level      GET current level, PUT ?level=debug to change it
+//	/maintenance    GET maintenance mode state, PUT to change it
+//	/metrics        Prometheus metrics, if enabled
+//	/sentry         sends a test error to Sentry
+//	/status         status page
+func (s *Server) AdminRouter() (http.Handler, error) {
+	conf := s.Config.Admin
+	if conf.Username == "" || conf.Password == "" {
+		return nil, errors.New("admin username or password is not set")
+	}
+
+	allowed, err := parseAllowedIPs(conf.AllowedIPs)
+	if err != nil {
+		return nil, fmt.Errorf("parse allowed IPs: %w", err)
+	}
+
+	r := chi.NewRouter()
+	r.Use(s.adminOnly(allowed))
+
+	r.Mount("/debug/pprof", s.PprofRouter())
+	r.Get("/build-info", buildInfo)
+	r.Get("/config", s.configDump)
+	r.Get("/log-level", logLevel)
+	r.Put("/log-level", setLogLevel)
+	r.Get("/maintenance", s.maintenanceState)
+	r.Put("/maintenance", s.setMaintenanceState)
+	r.Get("/sentry", sentry)
+	r.Get("/status", s.StatusPage)
+	if s.Config.Metrics.Enabled {
+		r.Handle("/metrics", metrics.Handler())
+	}
+
+	return r, nil
+}
+
+// adminOnly refuses requests from IPs not allowed with 403, and requests
+// without the admin credential with 401. The IP is the connection peer, see
+// PeerAddr, so clients can't pass the allowlist with X-Forwarded-For.
+func (s *Server) adminOnly(allowed []netip.Prefix) func(http.Handler) http.Handler {
+	username := []byte(s.Config.Admin.Username)
+	password := []byte(s.Config.Admin.Password)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			peerAddr := reqctx.GetPeerAddr(r.Context())
+			if !ipAllowed(peerAddr, allowed) {
+				ws.RespondError(w, r, http.StatusForbidden, fmt.Errorf("admin: IP %s is not allowed", peerAddr))
+				return
+			}
+
+			user, pass, _ := r.BasicAuth()
+			userOk := subtle.ConstantTimeCompare([]byte(user), username) == 1
+			passOk := subtle.ConstantTimeCompare([]byte(pass), password) == 1
+			if !userOk || !passOk {
+				w.Header().Set("WWW-Authenticate", `Basic realm="admin", charset="UTF-8"`)
+				ws.RespondError(w, r, http.StatusUnauthorized, errors.New("admin: invalid credentials"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// parseAllowedIPs parses IPs and CIDRs, single IP is a prefix of its own.
+func parseAllowedIPs(ips []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(ips))
+	for _, ip := range ips {
+		if strings.Contains(ip, "/") {
+			prefix, err := netip.ParsePrefix(ip)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(ip)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+
+	return prefixes, nil
+}
+
+// ipAllowed reports whether remote address, with or without port, is in any
+// of allowed prefixes.
+func ipAllowed(remoteAddr string, allowed []netip.Prefix) bool {
+	host := remoteAddr
+	if h, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		host = h
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, prefix := range allowed {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func buildInfo(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintf(w, "version\t%s\n", version.VERSION)
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		fmt.Fprint(w, info.String())
+	}
+}
+
+func (s *Server) configDump(w http.ResponseWriter, r *http.Request) {
+	ws.JSON(w, http.StatusOK, s.Config.Redacted())
+}
+
+func logLevel(w http.ResponseWriter, r *http.Request) {
+	ws.JSON(w, http.StatusOK, map[string]string{"level": slogger.Level().String()})
+}
+
+func setLogLevel(w http.ResponseWriter, r *http.Request) {
+	level, err := slogger.ParseLevel(r.URL.Query().Get("level"))
+	if err != nil {
+		ws.RespondError(w, r, http.StatusBadRequest, fmt.Errorf("parse level: %w", err))
+		return
+	}
+
+	prev := slogger.Level()
+	slogger.SetLevel(level)
+	slog.Warn("log level changed", slog.String("from", prev.String()), slog.String("to", level.String()))
+
+	ws.JSON(w, http.StatusOK, map[string]string{"level": level.String()})
+}
+
+func sentry(w http.ResponseWriter, r *http.Request) {
+	if err := alert.Errorf(r.Context(), errors.New("panika"), "request to sentry test endpoint on /sentry"); err != nil {
+		fmt.Fprintf(w, "Sentry message sent - %s\n", time.Now())
+		return
+	}
+	fmt.Fprintf(w, "Sentry did not sent - %s\n", time.Now())
+}
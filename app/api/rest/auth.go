@@ -0,0 +1,155 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/upper/db/v4"
+
+	"github.com/golang-cz/skeleton/data"
+	"github.com/golang-cz/skeleton/internal/reqctx"
+	"github.com/golang-cz/skeleton/proto"
+)
+
+// Authenticate verifies the Authorization header and stores the caller in
+// the request context:
+//
+//	Authorization: Bearer <JWT>     user and application ids
+//	Authorization: ApiKey <API key> API key, for machine callers
+//
+// Requests without credentials pass through unauthenticated, Authorize and
+// RPC methods require authentication on their own.
+//
+// Invalid or expired credentials get 401, valid tokens of deleted users and
+// API keys created by them get 403.
+func (s *Server) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme, credentials, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		credentials = strings.TrimSpace(credentials)
+
+		switch {
+		case strings.EqualFold(scheme, "Bearer") && s.Auth != nil:
+			s.authenticateBearer(next, w, r, credentials)
+		case strings.EqualFold(scheme, "ApiKey"):
+			s.authenticateApiKey(next, w, r, credentials)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+func (s *Server) authenticateBearer(next http.Handler, w http.ResponseWriter, r *http.Request, token string) {
+	ctx := r.Context()
+
+	claims, err := s.Auth.Verify(ctx, token)
+	if err != nil {
+		respondAuthError(w, r, proto.ErrUnauthenticated.WithCause(fmt.Errorf("verify token: %w", err)))
+		return
+	}
+
+	// Both were validated by Verify.
+	userId, _ := claims.UserId()
+	appId, _ := claims.AppId()
+
+	if _, err := s.DB.WithContext(ctx).User.FindActiveById(userId); err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			respondAuthError(w, r, proto.ErrPermissionDenied.WithCause(fmt.Errorf("user %v is not active", userId)))
+			return
+		}
+		respondError(w, r, proto.ErrWebrpcInternalError.WithCause(fmt.Errorf("load user: %w", err)))
+		return
+	}
+
+	ctx = reqctx.SetUserId(ctx, userId)
+	reqctx.AddAttr(ctx, "userId", userId)
+	if !appId.IsNil() {
+		ctx = reqctx.SetApplicationId(ctx, appId)
+		reqctx.AddAttr(ctx, "applicationId", appId)
+	}
+
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+func (s *Server) authenticateApiKey(next http.Handler, w http.ResponseWriter, r *http.Request, key string) {
+	ctx := r.Context()
+
+	apiKey, err := s.DB.WithContext(ctx).ApiKey.Authenticate(key)
+	if err != nil {
+		if errors.Is(err, data.ErrInvalidApiKey) {
+			respondAuthError(w, r, proto.ErrUnauthenticated.WithCause(err))
+			return
+		}
+		respondError(w, r, proto.ErrWebrpcInternalError.WithCause(fmt.Errorf("authenticate API key: %w", err)))
+		return
+	}
+
+	// Keys act on behalf of their creator, they stop working once the
+	// creator is deleted.
+	if apiKey.CreatedBy == nil {
+		respondAuthError(w, r, proto.ErrPermissionDenied.WithCause(fmt.Errorf("API key %s has no creator", apiKey.Prefix)))
+		return
+	}
+	if _, err := s.DB.WithContext(ctx).User.FindActiveById(*apiKey.CreatedBy); err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			respondAuthError(w, r, proto.ErrPermissionDenied.WithCause(fmt.Errorf("creator %v of API key %s is not active", *apiKey.CreatedBy, apiKey.Prefix)))
+			return
+		}
+		respondError(w, r, proto.ErrWebrpcInternalError.WithCause(fmt.Errorf("load API key creator: %w", err)))
+		return
+	}
+
+	scopes, err := s.grantedScopes(ctx, apiKey)
+	if err != nil {
+		respondError(w, r, proto.ErrWebrpcInternalError.WithCause(fmt.Errorf("get API key creator permissions: %w", err)))
+		return
+	}
+
+	ctx = reqctx.SetApiKey(ctx, &reqctx.ApiKey{
+		Id:     apiKey.ID,
+		Prefix: apiKey.Prefix,
+		Scopes: scopes,
+	})
+	reqctx.AddAttr(ctx, "apiKeyId", apiKey.ID)
+	reqctx.AddAttr(ctx, "apiKeyPrefix", apiKey.Prefix)
+
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// grantedScopes returns scopes of the key its creator still holds the
+// permissions for in the application of the key, so keys can't do more than
+// their creator.
+func (s *Server) grantedScopes(ctx context.Context, apiKey *data.ApiKey) ([]string, error) {
+	var appId uuid.UUID
+	if apiKey.ApplicationId != nil {
+		appId = *apiKey.ApplicationId
+	}
+
+	grants, err := s.RBAC.Grants(ctx, *apiKey.CreatedBy, appId)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := make([]string, 0, len(apiKey.Scopes))
+	for _, scope := range apiKey.Scopes {
+		if permission, ok := s.ScopePermissions[scope]; ok && grants.Has(permission) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return scopes, nil
+}
+
+func respondAuthError(w http.ResponseWriter, r *http.Request, rpcErr proto.WebRPCError) {
+	reqctx.AddAttr(r.Context(), "webrpcError", rpcErr)
+
+	if rpcErr.HTTPStatus == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	}
+
+	rpcErr.Cause = "" // Don't tell clients why exactly the credentials were rejected.
+	proto.RespondWithError(w, rpcErr.WithRequestId(reqctx.GetRequestId(r.Context())))
+}
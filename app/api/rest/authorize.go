@@ -0,0 +1,59 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/golang-cz/skeleton/internal/reqctx"
+	"github.com/golang-cz/skeleton/proto"
+)
+
+// Authorize refuses RPC calls of users missing permissions the method
+// requires, see rpc.MethodPermissions. Permissions come from roles of the
+// user in the application of the token. API keys are limited by their
+// scopes, checked by the methods.
+//
+// Anonymous calls of methods requiring permissions get 401, users missing
+// a permission get 403.
+func (s *Server) Authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		// Unknown methods are refused by the RPC server.
+		required := s.MethodPermissions[rpcMethodKey(r.URL.Path)]
+		if len(required) == 0 || reqctx.GetApiKey(ctx) != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		userId := reqctx.GetUserId(ctx)
+		if userId.IsNil() {
+			respondError(w, r, proto.ErrUnauthenticated.WithCause(errors.New("authentication required")))
+			return
+		}
+
+		grants, err := s.RBAC.Grants(ctx, userId, reqctx.GetApplicationId(ctx))
+		if err != nil {
+			respondError(w, r, proto.ErrWebrpcInternalError.WithCause(fmt.Errorf("get permissions: %w", err)))
+			return
+		}
+
+		for _, permission := range required {
+			if !grants.Has(permission) {
+				respondError(w, r, proto.ErrPermissionDenied.WithCause(fmt.Errorf("permission %q required", permission)))
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rpcMethodKey returns "Service.Method" of RPC call, taken from the last two
+// segments of its path, eg. /rpc/Skeleton/GetUser.
+func rpcMethodKey(urlPath string) string {
+	service, method := path.Split(urlPath)
+	return path.Base(service) + "." + method
+}
@@ -0,0 +1,46 @@
+package rest
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/golang-cz/skeleton/internal/reqctx"
+)
+
+// ClientCert stores identity of the TLS client certificate in the request
+// context, see reqctx.GetClientCert. Only certificates verified against the
+// client CA are trusted, ie. none unless mTLS is configured.
+func (s *Server) ClientCert(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		clientCert := clientCertIdentity(r.TLS.VerifiedChains[0][0])
+		reqctx.AddAttr(r.Context(), "clientCert", clientCert.CommonName)
+
+		ctx := reqctx.SetClientCert(r.Context(), clientCert)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func clientCertIdentity(cert *x509.Certificate) *reqctx.ClientCert {
+	fingerprint := sha256.Sum256(cert.Raw)
+
+	uris := make([]string, len(cert.URIs))
+	for i, uri := range cert.URIs {
+		uris[i] = uri.String()
+	}
+
+	return &reqctx.ClientCert{
+		CommonName:    cert.Subject.CommonName,
+		Organizations: cert.Subject.Organization,
+		DNSNames:      cert.DNSNames,
+		URIs:          uris,
+		SerialNumber:  cert.SerialNumber.String(),
+		Fingerprint:   hex.EncodeToString(fingerprint[:]),
+	}
+}
@@ -0,0 +1,80 @@
+package rest
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/cors"
+
+	"github.com/golang-cz/skeleton/config"
+)
+
+// CORS route groups, see config.HTTP.CORS.
+const (
+	corsGroupRPC    = "rpc"
+	corsGroupStatus = "status"
+	corsGroupAdmin  = "admin"
+)
+
+// CORS applies CORS policy of the route group the request path belongs to
+// and answers preflight requests. It goes before health probes, so they
+// can be called cross-origin too.
+func (s *Server) CORS() func(http.Handler) http.Handler {
+	policies := map[string]*cors.Cors{}
+	for group, conf := range s.Config.HTTP.CORS {
+		if len(conf.AllowedOrigins) > 0 {
+			policies[group] = newCORS(conf)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		handlers := make(map[string]http.Handler, len(policies))
+		for group, policy := range policies {
+			handlers[group] = policy.Handler(next)
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if h, ok := handlers[corsGroup(r.URL.Path)]; ok {
+				h.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// AdminCORS applies CORS policy of admin route group, for admin router on
+// its own listener.
+func (s *Server) AdminCORS(next http.Handler) http.Handler {
+	conf, ok := s.Config.HTTP.CORS[corsGroupAdmin]
+	if !ok || len(conf.AllowedOrigins) == 0 {
+		return next
+	}
+	return newCORS(conf).Handler(next)
+}
+
+func newCORS(conf config.CORS) *cors.Cors {
+	return cors.New(cors.Options{
+		AllowedOrigins:   conf.AllowedOrigins,
+		AllowedMethods:   conf.AllowedMethods,
+		AllowedHeaders:   conf.AllowedHeaders,
+		ExposedHeaders:   conf.ExposedHeaders,
+		AllowCredentials: conf.AllowCredentials,
+		MaxAge:           int(time.Duration(conf.MaxAge) / time.Second),
+	})
+}
+
+// corsGroup returns route group of the path, or empty string for paths
+// outside of any group.
+func corsGroup(path string) string {
+	switch {
+	case strings.HasPrefix(path, "/_api/rpc/"):
+		return corsGroupRPC
+	case path == "/_api/ping", path == livezPath, path == readyzPath:
+		return corsGroupStatus
+	case path == "/_admin", strings.HasPrefix(path, "/_admin/"):
+		return corsGroupAdmin
+	}
+	return ""
+}
@@ -0,0 +1,17 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/golang-cz/skeleton/internal/reqctx"
+	"github.com/golang-cz/skeleton/proto"
+)
+
+// respondError logs the error with the request and sends it to the client,
+// including the request id.
+func respondError(w http.ResponseWriter, r *http.Request, rpcErr proto.WebRPCError) {
+	ctx := r.Context()
+	reqctx.AddAttr(ctx, "webrpcError", rpcErr)
+
+	proto.RespondWithError(w, rpcErr.WithRequestId(reqctx.GetRequestId(ctx)))
+}
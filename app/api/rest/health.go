@@ -0,0 +1,79 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/golang-cz/skeleton/pkg/status"
+	"github.com/golang-cz/skeleton/pkg/ws"
+)
+
+const (
+	livezPath        = "/_api/livez"
+	readyzPath       = "/_api/readyz"
+	readinessTimeout = 5 * time.Second
+)
+
+// Health serves liveness and readiness probes. Like Heartbeat, it goes
+// before logging, metrics etc., so frequent probes don't flood them.
+//
+//	/_api/livez   200 while the process serves requests
+//	/_api/readyz  200 if DB and NATS are reachable and DB schema is current,
+//	              503 otherwise or while draining
+func (s *Server) Health(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			switch r.URL.Path {
+			case livezPath:
+				w.Header().Set("Content-Type", "text/plain")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte("ok\n"))
+				return
+			case readyzPath:
+				s.readyz(w, r)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+type readiness struct {
+	Ready    bool     `json:"ready"`
+	Draining bool     `json:"draining,omitempty"`
+	Probes   []result `json:"probes,omitempty"`
+}
+
+func (s *Server) readyz(w http.ResponseWriter, r *http.Request) {
+	if s.draining.Load() {
+		ws.JSON(w, http.StatusServiceUnavailable, readiness{Draining: true})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	resp := readiness{
+		Ready:  true,
+		Probes: run(ctx, s.readinessProbes()),
+	}
+	for _, res := range resp.Probes {
+		if res.Status != status.ProbeStatusHealthy {
+			resp.Ready = false
+		}
+	}
+
+	code := http.StatusOK
+	if !resp.Ready {
+		code = http.StatusServiceUnavailable
+	}
+	ws.JSON(w, code, resp)
+}
+
+// Drain makes readiness probe fail from now on, so load balancers stop
+// routing requests here before the server shuts down.
+func (s *Server) Drain() {
+	s.draining.Store(true)
+}
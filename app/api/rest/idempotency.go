@@ -0,0 +1,167 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/golang-cz/skeleton/internal/reqctx"
+	"github.com/golang-cz/skeleton/pkg/idempotency"
+	"github.com/golang-cz/skeleton/pkg/slogger"
+	"github.com/golang-cz/skeleton/proto"
+)
+
+const (
+	idempotencyKeyHeader    = "Idempotency-Key"
+	idempotentReplayHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength = 255
+	idempotencyPollInterval = 100 * time.Millisecond
+)
+
+// Idempotency makes POST requests with Idempotency-Key header safe to retry.
+// The first successful response is stored for the caller and the key, and
+// retries get it back with Idempotent-Replayed header instead of being
+// processed again. Failed requests aren't stored, so they can be retried.
+//
+// A key reused for a different request gets 422. A duplicate sent while the
+// first request is still in progress waits for it to finish, or gets 409.
+//
+// Keys are scoped to the authenticated user and application or API key,
+// anonymous requests are processed as usual.
+func (s *Server) Idempotency(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if s.IdempotencyStore == nil || key == "" || r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := r.Context()
+
+		var scope string
+		if userId := reqctx.GetUserId(ctx); !userId.IsNil() {
+			// Tokens of other applications may be granted different
+			// permissions, so they must not get the stored response.
+			scope = "user=" + userId.String() + ":app=" + reqctx.GetApplicationId(ctx).String()
+		} else if apiKey := reqctx.GetApiKey(ctx); apiKey != nil {
+			scope = "apikey=" + apiKey.Id.String()
+		} else {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			respondError(w, r, proto.ErrWebrpcBadRequest.WithCause(fmt.Errorf("%s header is longer than %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength)))
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			respondError(w, r, proto.ErrWebrpcBadRequest.WithCause(fmt.Errorf("read request body: %w", err)))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		conf := s.Config.Idempotency
+		storeKey := scope + ":" + key
+		requestHash := idempotency.RequestHash(r.URL.Path, body)
+		reqctx.AddAttr(ctx, "idempotencyKey", key)
+
+		token, err := idempotency.NewToken()
+		if err != nil {
+			respondError(w, r, proto.ErrWebrpcInternalError.WithCause(fmt.Errorf("idempotency key: %w", err)))
+			return
+		}
+
+		deadline := time.Now().Add(time.Duration(conf.Wait))
+		for {
+			record, reserved, err := s.IdempotencyStore.Reserve(ctx, storeKey, token, requestHash, time.Duration(conf.LockTimeout))
+			if err != nil {
+				respondError(w, r, proto.ErrWebrpcInternalError.WithCause(fmt.Errorf("reserve idempotency key: %w", err)))
+				return
+			}
+
+			switch {
+			case reserved:
+				s.serveIdempotent(next, w, r, storeKey, token, requestHash)
+				return
+
+			case !bytes.Equal(record.RequestHash, requestHash):
+				respondError(w, r, proto.ErrIdempotencyKeyReused.WithCause(fmt.Errorf("key %q was used for a different request", key)))
+				return
+
+			case record.Response != nil:
+				reqctx.AddAttr(ctx, "idempotentReplay", true)
+				if record.Response.ContentType != "" {
+					w.Header().Set("Content-Type", record.Response.ContentType)
+				}
+				w.Header().Set(idempotentReplayHeader, "true")
+				w.WriteHeader(record.Response.Status)
+				w.Write(record.Response.Body)
+				return
+			}
+
+			// The first request is in progress.
+			if time.Now().Add(idempotencyPollInterval).After(deadline) {
+				respondError(w, r, proto.ErrIdempotencyKeyInProgress.WithCause(fmt.Errorf("request with key %q is in progress", key)))
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(idempotencyPollInterval):
+			}
+		}
+	})
+}
+
+// serveIdempotent serves request holding the reserved key and stores its
+// response, or releases the key if the request failed. If the request took
+// longer than the lock timeout and the key was taken over meanwhile, the
+// response isn't stored.
+func (s *Server) serveIdempotent(next http.Handler, w http.ResponseWriter, r *http.Request, storeKey, token string, requestHash []byte) {
+	ctx := r.Context()
+
+	var body bytes.Buffer
+	ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+	ww.Tee(&body)
+
+	completed := false
+	defer func() {
+		if completed {
+			return
+		}
+		// Panic or failure, let the client retry. The request context may be
+		// canceled already.
+		if err := s.IdempotencyStore.Release(context.WithoutCancel(ctx), storeKey, token); err != nil {
+			err = fmt.Errorf("release idempotency key: %w", err)
+			slog.ErrorContext(ctx, slogger.ErrorCause(err).Error())
+		}
+	}()
+
+	next.ServeHTTP(ww, r)
+
+	status := ww.Status()
+	if status < 200 || status >= 300 {
+		return
+	}
+
+	resp := &idempotency.Response{
+		Status:      status,
+		ContentType: ww.Header().Get("Content-Type"),
+		Body:        body.Bytes(),
+	}
+	if err := s.IdempotencyStore.Complete(context.WithoutCancel(ctx), storeKey, token, requestHash, resp, time.Duration(s.Config.Idempotency.TTL)); err != nil {
+		err = fmt.Errorf("store idempotent response: %w", err)
+		slog.ErrorContext(ctx, slogger.ErrorCause(err).Error())
+		return
+	}
+	completed = true
+}
@@ -0,0 +1,79 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/golang-cz/skeleton/config"
+	"github.com/golang-cz/skeleton/internal/reqctx"
+	"github.com/golang-cz/skeleton/pkg/maintenance"
+	"github.com/golang-cz/skeleton/pkg/ws"
+	"github.com/golang-cz/skeleton/proto"
+)
+
+// Maintenance refuses requests with 503 and Retry-After while maintenance
+// mode is on. Admin router and allowlisted paths and IPs are served. Health
+// probes go before it, so replicas stay ready.
+func Maintenance(mode *maintenance.Mode, conf config.Maintenance) (func(http.Handler) http.Handler, error) {
+	allowed, err := parseAllowedIPs(conf.AllowedIPs)
+	if err != nil {
+		return nil, fmt.Errorf("parse allowed IPs: %w", err)
+	}
+	allowedPaths := append([]string{"/_admin/"}, conf.AllowedPaths...)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			state := mode.State()
+			if !state.Enabled || maintenanceAllowed(r, allowedPaths, allowed) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Retry-After", seconds(state.RetryAfter(time.Duration(conf.RetryAfter))))
+
+			cause := state.Reason
+			if state.ETA != nil {
+				cause = strings.TrimSpace(fmt.Sprintf("%s (ETA %s)", cause, state.ETA.Format(time.RFC3339)))
+			}
+			respondError(w, r, proto.ErrMaintenance.WithCause(errors.New(cause)))
+		})
+	}, nil
+}
+
+func maintenanceAllowed(r *http.Request, paths []string, ips []netip.Prefix) bool {
+	for _, path := range paths {
+		if strings.HasPrefix(r.URL.Path, path) {
+			return true
+		}
+	}
+	// Connection peer, clients can't pass with X-Forwarded-For.
+	return ipAllowed(reqctx.GetPeerAddr(r.Context()), ips)
+}
+
+func (s *Server) maintenanceState(w http.ResponseWriter, r *http.Request) {
+	ws.JSON(w, http.StatusOK, s.MaintenanceMode.State())
+}
+
+// setMaintenanceState turns maintenance mode on or off on all replicas, eg.
+//
+//	PUT /_admin/maintenance
+//	{"enabled": true, "reason": "DB migration", "eta": "2026-10-19T18:00:00Z"}
+func (s *Server) setMaintenanceState(w http.ResponseWriter, r *http.Request) {
+	var state maintenance.State
+	if err := json.NewDecoder(r.Body).Decode(&state); err != nil {
+		ws.RespondError(w, r, http.StatusBadRequest, fmt.Errorf("decode state: %w", err))
+		return
+	}
+
+	if err := s.MaintenanceMode.Set(r.Context(), state); err != nil {
+		ws.RespondError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	ws.JSON(w, http.StatusOK, s.MaintenanceMode.State())
+}
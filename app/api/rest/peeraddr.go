@@ -0,0 +1,19 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/golang-cz/skeleton/internal/reqctx"
+)
+
+// PeerAddr stores address of the connection peer in the request context,
+// see reqctx.GetPeerAddr. It must run before middleware.RealIP, which
+// replaces RemoteAddr with the client IP taken from request headers. The
+// headers are set by the client unless a proxy overwrites them, so IP
+// allowlists check the peer address instead.
+func (s *Server) PeerAddr(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := reqctx.SetPeerAddr(r.Context(), r.RemoteAddr)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -7,6 +7,10 @@ import (
 	"github.com/go-chi/chi/v5"
 )
 
+// Profiles served by runtime/pprof. Block and mutex profiles are empty
+// unless enabled in [admin] config.
+var pprofProfiles = []string{"allocs", "block", "goroutine", "heap", "mutex", "threadcreate"}
+
 func (s *Server) PprofRouter() http.Handler {
 	r := chi.NewRouter()
 
@@ -15,10 +19,11 @@ func (s *Server) PprofRouter() http.Handler {
 		r.Get("/cmdline", pprof.Cmdline)
 		r.Get("/profile", pprof.Profile)
 		r.Get("/symbol", pprof.Symbol)
+		r.Post("/symbol", pprof.Symbol)
 		r.Get("/trace", pprof.Trace)
-		r.Get("/heap", func(w http.ResponseWriter, r *http.Request) {
-			pprof.Handler("heap").ServeHTTP(w, r)
-		})
+		for _, name := range pprofProfiles {
+			r.Handle("/"+name, pprof.Handler(name))
+		}
 	})
 
 	return r
@@ -0,0 +1,150 @@
+package rest
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/golang-cz/skeleton/internal/reqctx"
+	"github.com/golang-cz/skeleton/pkg/ratelimit"
+	"github.com/golang-cz/skeleton/pkg/slogger"
+	"github.com/golang-cz/skeleton/proto"
+)
+
+// RateLimit refuses requests exceeding any of the rate limit rules matching
+// the request path with 429 and Retry-After header. The state of the most
+// limiting rule is sent in RateLimit-* headers:
+//
+//	RateLimit-Limit: 100        max requests at once
+//	RateLimit-Remaining: 42     requests left
+//	RateLimit-Reset: 35         seconds until the limit fully recovers
+//	RateLimit-Policy: 100;w=60  requests per window in seconds
+//
+// If the rate limit backend fails, requests are allowed.
+//
+// Rules keyed by "ip" and "method" only are checked by RateLimit before
+// authentication, so guessing of credentials is limited too. Rules keyed by
+// the caller are checked by RateLimitAuthenticated.
+func (s *Server) RateLimit(next http.Handler) http.Handler {
+	return s.rateLimit(next, false)
+}
+
+// RateLimitAuthenticated checks rate limit rules keyed by "user" or
+// "api_key", see RateLimit. It must run after Authenticate.
+func (s *Server) RateLimitAuthenticated(next http.Handler) http.Handler {
+	return s.rateLimit(next, true)
+}
+
+func (s *Server) rateLimit(next http.Handler, authenticated bool) http.Handler {
+	rpcMethods := rpcMethods()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.RateLimiter == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := r.Context()
+
+		res, ok, err := s.RateLimiter.Allow(ctx, r.URL.Path, authenticated, func(part string) string {
+			return rateLimitKey(r, part, rpcMethods)
+		})
+		if err != nil {
+			err = fmt.Errorf("rate limit: %w", err)
+			slog.Error(slogger.ErrorCause(err).Error())
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+
+		// Keep headers of the rule checked before authentication, if it's
+		// closer to the limit.
+		if remaining, err := strconv.Atoi(h.Get("RateLimit-Remaining")); err == nil && res.Allowed && remaining <= res.Remaining {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("RateLimit-Reset", seconds(res.Reset))
+		h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%s", res.Limit, seconds(res.Period)))
+
+		if !res.Allowed {
+			h.Set("Retry-After", seconds(res.RetryAfter))
+
+			respondError(w, r, proto.ErrRateLimited.WithCause(fmt.Errorf("retry after %v", res.RetryAfter.Round(time.Millisecond))))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitKey returns value of given part of the bucket key. Anonymous
+// requests are keyed by IP instead of user or API key.
+func rateLimitKey(r *http.Request, part string, rpcMethods []string) string {
+	ctx := r.Context()
+
+	switch part {
+	case ratelimit.KeyUser:
+		if userId := reqctx.GetUserId(ctx); !userId.IsNil() {
+			return "user=" + userId.String()
+		}
+	case ratelimit.KeyApiKey:
+		if apiKey := reqctx.GetApiKey(ctx); apiKey != nil {
+			return "apikey=" + apiKey.Id.String()
+		}
+	case ratelimit.KeyMethod:
+		return "method=" + rateLimitMethod(r, rpcMethods)
+	}
+
+	// Not RemoteAddr rewritten by RealIP middleware, clients would pick
+	// a new bucket by sending a different X-Forwarded-For.
+	ip := reqctx.GetPeerAddr(ctx)
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	return "ip=" + ip
+}
+
+// rateLimitMethod returns RPC method name or route pattern of the request.
+// Clients choose the path, so only known methods and routes get their own
+// bucket, everything else shares one.
+func rateLimitMethod(r *http.Request, rpcMethods []string) string {
+	if strings.Contains(r.URL.Path, "/rpc/") {
+		if method := path.Base(r.URL.Path); slices.Contains(rpcMethods, method) {
+			return method
+		}
+		return "unknown"
+	}
+
+	// The request isn't routed yet, match it against the routes.
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil {
+		return "unknown"
+	}
+	match := chi.NewRouteContext()
+	if !rctx.Routes.Match(match, r.Method, r.URL.Path) {
+		return "unknown"
+	}
+	return match.RoutePattern()
+}
+
+// seconds formats duration as whole seconds, rounded up.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
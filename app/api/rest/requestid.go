@@ -0,0 +1,26 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/golang-cz/skeleton/internal/reqctx"
+)
+
+// RequestId stores id of the request in the request context, so it can be
+// correlated across logs, errors, Sentry events, NATS messages and SQL
+// queries. Id sent by the client or a proxy in X-Request-Id header is used if
+// it's valid, otherwise a new one is generated. The id is sent back in the
+// same response header.
+func (s *Server) RequestId(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestId := r.Header.Get(reqctx.RequestIdHeader)
+		if !reqctx.ValidRequestId(requestId) {
+			requestId = reqctx.NewRequestId()
+		}
+
+		w.Header().Set(reqctx.RequestIdHeader, requestId)
+
+		ctx := reqctx.SetRequestId(r.Context(), requestId)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -1,50 +1,71 @@
 package rest
 
 import (
-	"errors"
 	"fmt"
 	"net/http"
-	"time"
+	"reflect"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/rs/cors"
 
-	"github.com/golang-cz/skeleton/pkg/alert"
+	"github.com/golang-cz/skeleton/pkg/metrics"
 	"github.com/golang-cz/skeleton/pkg/slogger"
+	"github.com/golang-cz/skeleton/pkg/static"
+	"github.com/golang-cz/skeleton/pkg/tracing"
+	"github.com/golang-cz/skeleton/proto"
 )
 
-func (s *Server) Router(rpcServerHandler http.Handler) chi.Router {
+// Router is the API router. Admin router is mounted on /_admin, unless it's
+// nil, ie. disabled or served on its own listener.
+func (s *Server) Router(rpcServerHandler http.Handler, adminRouter http.Handler) chi.Router {
 	r := chi.NewRouter()
 
-	r.Use(middleware.NoCache)
+	r.Use(s.PeerAddr)
+	r.Use(s.noCache)
+	r.Use(s.SecurityHeaders())
+	r.Use(s.CORS())
 	r.Use(middleware.Heartbeat("/_api/ping"))
+	r.Use(s.Health)
 	r.Use(middleware.RealIP)
+	r.Use(s.RequestId)
+	r.Use(tracing.HTTP)
+	if s.Config.Metrics.Enabled {
+		r.Use(metrics.HTTP)
+	}
 	r.Use(slogger.SloggerMiddleware(s.Config))
+	r.Use(s.ClientCert)
+	r.Use(s.Compress)
 	r.Use(middleware.Recoverer)
-
-	corsHandler := cors.New(cors.Options{
-		AllowedOrigins: s.Config.AllowedOrigins,
-		AllowedMethods: []string{"GET", "POST", "OPTIONS"},
-		AllowedHeaders: []string{
-			"Accept", "Authorization", "Content-Type",
-		},
-		AllowCredentials: true,
-		MaxAge:           300, // Maximum value not ignored by any of major browsers
-	})
-
-	r.Use(corsHandler.Handler)
+	r.Use(s.Maintenance)
+	r.Use(s.RateLimit)
+	r.Use(s.Authenticate)
+	r.Use(s.RateLimitAuthenticated)
 
 	r.Get("/robots.txt", robots)
-	r.Get("/sentry", sentry)
-	r.Get("/favicon.ico", favicon)
-	r.Mount("/debug/pprof", s.PprofRouter())
+	if s.Static != nil {
+		mountPath := static.MountPath(s.Config.Static)
+		r.Handle(mountPath+"*", s.Static)
+		if mountPath != "/" {
+			r.Handle(strings.TrimSuffix(mountPath, "/"), s.Static)
+		}
+	} else {
+		r.Get("/favicon.ico", favicon)
+	}
+	if adminRouter != nil {
+		r.Mount("/_admin", adminRouter)
+	}
 
 	r.Route("/_api", func(r chi.Router) {
-		r.Get("/status", s.StatusPage)
-
 		r.Route("/rpc", func(r chi.Router) {
 			r.Use(stripPrefixBefore("/rpc/"))
+			methods := rpcMethods()
+			if s.Config.Metrics.Enabled {
+				r.Use(metrics.RPC(methods))
+			}
+			r.Use(tracing.RPC(methods))
+			r.Use(s.Authorize)
+			r.Use(s.Idempotency)
 
 			r.HandleFunc("/*", rpcServerHandler.ServeHTTP)
 		})
@@ -53,17 +74,34 @@ func (s *Server) Router(rpcServerHandler http.Handler) chi.Router {
 	return r
 }
 
-func robots(w http.ResponseWriter, r *http.Request) {
-	// Disallow all robots. We don't want to be indexed by Google etc.
-	fmt.Fprintf(w, "User-agent: *\nDisallow: /\n")
+// noCache disables caching of responses, except of static files, which
+// are cached by their ETag or for good if they're immutable.
+func (s *Server) noCache(next http.Handler) http.Handler {
+	noCache := middleware.NoCache(next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.Static != nil && s.Static.Match(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		noCache.ServeHTTP(w, r)
+	})
 }
 
-func sentry(w http.ResponseWriter, r *http.Request) {
-	if err := alert.Msgf(r.Context(), "request to sentry test endpoint on /sentry", errors.New("panika")); err != nil {
-		fmt.Fprintf(w, "Sentry message sent - %s\n", time.Now())
-		return
+// rpcMethods returns names of all methods of the RPC service.
+func rpcMethods() []string {
+	t := reflect.TypeOf((*proto.Skeleton)(nil)).Elem()
+
+	methods := make([]string, t.NumMethod())
+	for i := range methods {
+		methods[i] = t.Method(i).Name
 	}
-	fmt.Fprintf(w, "Sentry did not sent - %s\n", time.Now())
+	return methods
+}
+
+func robots(w http.ResponseWriter, r *http.Request) {
+	// Disallow all robots. We don't want to be indexed by Google etc.
+	fmt.Fprintf(w, "User-agent: *\nDisallow: /\n")
 }
 
 func favicon(w http.ResponseWriter, r *http.Request) {
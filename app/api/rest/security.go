@@ -0,0 +1,52 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// SecurityHeaders sets security headers configured in config.Security on
+// every response.
+func (s *Server) SecurityHeaders() func(http.Handler) http.Handler {
+	conf := s.Config.HTTP.Security
+
+	headers := map[string]string{
+		"Content-Security-Policy": conf.ContentSecurityPolicy,
+		"X-Frame-Options":         conf.FrameOptions,
+		"Referrer-Policy":         conf.ReferrerPolicy,
+		"Permissions-Policy":      conf.PermissionsPolicy,
+	}
+	if conf.NoSniff {
+		headers["X-Content-Type-Options"] = "nosniff"
+	}
+	if maxAge := time.Duration(conf.HSTSMaxAge); maxAge > 0 {
+		hsts := fmt.Sprintf("max-age=%d", int(maxAge.Seconds()))
+		if conf.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if conf.HSTSPreload {
+			hsts += "; preload"
+		}
+		headers["Strict-Transport-Security"] = hsts
+	}
+	for key, value := range headers {
+		if value == "" {
+			delete(headers, key)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		if len(headers) == 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			for key, value := range headers {
+				h.Set(key, value)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
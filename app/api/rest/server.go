@@ -1,11 +1,36 @@
 package rest
 
 import (
+	"net/http"
+	"sync/atomic"
+
 	"github.com/golang-cz/skeleton/config"
 	"github.com/golang-cz/skeleton/data"
+	"github.com/golang-cz/skeleton/pkg/idempotency"
+	"github.com/golang-cz/skeleton/pkg/jwtauth"
+	"github.com/golang-cz/skeleton/pkg/maintenance"
+	"github.com/golang-cz/skeleton/pkg/ratelimit"
+	"github.com/golang-cz/skeleton/pkg/rbac"
+	"github.com/golang-cz/skeleton/pkg/static"
 )
 
 type Server struct {
 	Config *config.Config
 	DB     *data.Database
+	Auth   *jwtauth.Verifier // Nil if JWT auth isn't configured.
+
+	RBAC              *rbac.Authorizer
+	MethodPermissions map[string][]string // Permissions required by "Service.Method", see rpc.MethodPermissions.
+	ScopePermissions  map[string]string   // Permissions API key creators need for the scopes, see rpc.ScopePermissions.
+
+	RateLimiter      *ratelimit.Limiter // Nil if rate limiting is disabled.
+	IdempotencyStore idempotency.Store  // Nil if idempotency keys are disabled.
+
+	Compress    func(http.Handler) http.Handler // Response compression, see compress.Middleware.
+	Maintenance func(http.Handler) http.Handler // Refuses requests in maintenance mode, see Maintenance.
+
+	MaintenanceMode *maintenance.Mode
+	Static          *static.Server // Nil if serving of the frontend is disabled.
+
+	draining atomic.Bool
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/upper/db/v4"
 
 	"github.com/golang-cz/skeleton/pkg/events"
+	"github.com/golang-cz/skeleton/pkg/maintenance"
 	"github.com/golang-cz/skeleton/pkg/status"
 	"github.com/golang-cz/skeleton/pkg/ws"
 )
@@ -41,14 +42,7 @@ func (s *Server) StatusPage(w http.ResponseWriter, r *http.Request) {
 		},
 	}
 
-	uptimeProbes := []probe{
-		{
-			Key: "SkeletonDb",
-			Probe: &status.Postgres{
-				GetDB: func() db.Session { return s.DB.Session },
-			},
-		},
-	}
+	uptimeProbes := s.readinessProbes()
 
 	results := run(ctx, append(uptimeProbes, serviceProbes...))
 
@@ -57,11 +51,19 @@ func (s *Server) StatusPage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var maintenanceState *maintenance.State
+	if s.MaintenanceMode != nil {
+		state := s.MaintenanceMode.State()
+		maintenanceState = &state
+	}
+
 	i := len(uptimeProbes) // Helper index to split the slice.
 	statusPage, err := status.RenderTemplate(struct {
+		Maintenance *maintenance.State
 		Uptime      []result
 		ServiceInfo []result
 	}{
+		Maintenance: maintenanceState,
 		Uptime:      results[:i],
 		ServiceInfo: results[i:],
 	})
@@ -73,6 +75,28 @@ func (s *Server) StatusPage(w http.ResponseWriter, r *http.Request) {
 	ws.HTML(w, 200, statusPage)
 }
 
+// readinessProbes check dependencies the API can't serve requests without.
+func (s *Server) readinessProbes() []probe {
+	return []probe{
+		{
+			Key: "SkeletonDb",
+			Probe: &status.Postgres{
+				GetDB: func() db.Session { return s.DB.Session },
+			},
+		},
+		{
+			Key: "SkeletonDbMigrations",
+			Probe: &status.Migrations{
+				GetDB: func() db.Session { return s.DB.Session },
+			},
+		},
+		{
+			Key:   "NATS",
+			Probe: &status.NATS{},
+		},
+	}
+}
+
 func run(ctx context.Context, probes []probe) []result {
 	results := make([]result, len(probes))
 
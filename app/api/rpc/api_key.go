@@ -0,0 +1,95 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang-cz/skeleton/data"
+	"github.com/golang-cz/skeleton/proto"
+)
+
+func (r *Rpc) CreateApiKey(ctx context.Context, name string, scopes []string, expiresAt time.Time) (*proto.ApiKey, string, error) {
+	userId, err := requireUser(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	appId, err := requireApplication(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if name == "" {
+		return nil, "", data.Invalidf("name is required")
+	}
+
+	grants, err := r.RBAC.Grants(ctx, userId, appId)
+	if err != nil {
+		return nil, "", fmt.Errorf("get permissions: %w", err)
+	}
+	for _, scope := range scopes {
+		permission, ok := scopePermissions[scope]
+		if !ok {
+			return nil, "", data.Invalidf("unknown scope %q", scope)
+		}
+		if !grants.Has(permission) {
+			return nil, "", proto.ErrPermissionDenied.WithCause(fmt.Errorf("permission %q required for scope %q", permission, scope))
+		}
+	}
+
+	var expires *time.Time
+	if !expiresAt.IsZero() {
+		if expiresAt.Before(time.Now()) {
+			return nil, "", data.Invalidf("expiresAt is in the past")
+		}
+		expires = &expiresAt
+	}
+
+	apiKey, key, err := data.NewApiKey(name, scopes, expires, &userId, &appId)
+	if err != nil {
+		return nil, "", fmt.Errorf("generate api key: %w", err)
+	}
+
+	if err := r.DB.WithContext(ctx).Save(apiKey); err != nil {
+		return nil, "", fmt.Errorf("save api key: %w", err)
+	}
+
+	return apiKey.ApiKey, key, nil
+}
+
+func (r *Rpc) ListApiKeys(ctx context.Context) ([]*proto.ApiKey, error) {
+	userId, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeys, err := r.DB.WithContext(ctx).ApiKey.FindAllByCreator(userId)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+
+	out := make([]*proto.ApiKey, len(apiKeys))
+	for i, apiKey := range apiKeys {
+		out[i] = apiKey.ApiKey
+	}
+
+	return out, nil
+}
+
+func (r *Rpc) RevokeApiKey(ctx context.Context, id string) error {
+	userId, err := requireUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	apiKeyId, err := parseId("id", id)
+	if err != nil {
+		return err
+	}
+
+	if err := r.DB.WithContext(ctx).ApiKey.Revoke(apiKeyId, userId); err != nil {
+		return fmt.Errorf("revoke api key: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/gofrs/uuid/v5"
+
+	"github.com/golang-cz/skeleton/internal/reqctx"
+	"github.com/golang-cz/skeleton/proto"
+)
+
+// API key scopes. Users aren't limited by scopes.
+const (
+	ScopeUsersRead   = "users:read"
+	ScopeUsersWrite  = "users:write"
+	ScopeUsersDelete = "users:delete"
+)
+
+// scopePermissions are permissions users need to create API keys with the
+// scopes, so keys can't do more than their creator.
+var scopePermissions = map[string]string{
+	ScopeUsersRead:   PermUsersRead,
+	ScopeUsersWrite:  PermUsersWrite,
+	ScopeUsersDelete: PermUsersDelete,
+}
+
+// ScopePermissions returns permissions API key creators need for the scopes.
+// Keys lose scopes once their creator loses the permissions.
+func ScopePermissions() map[string]string {
+	return maps.Clone(scopePermissions)
+}
+
+// requireAuth returns Unauthenticated error for anonymous requests. Both
+// users and API keys are accepted.
+func requireAuth(ctx context.Context) error {
+	if reqctx.GetUserId(ctx).IsNil() && reqctx.GetApiKey(ctx) == nil {
+		return proto.ErrUnauthenticated.WithCause(errors.New("authentication required"))
+	}
+
+	return nil
+}
+
+// requireUser returns id of the authenticated user. API keys are refused.
+func requireUser(ctx context.Context) (uuid.UUID, error) {
+	if err := requireAuth(ctx); err != nil {
+		return uuid.Nil, err
+	}
+
+	userId := reqctx.GetUserId(ctx)
+	if userId.IsNil() {
+		return uuid.Nil, proto.ErrPermissionDenied.WithCause(errors.New("user authentication required"))
+	}
+
+	return userId, nil
+}
+
+// requireApplication returns application of the authenticated user.
+func requireApplication(ctx context.Context) (uuid.UUID, error) {
+	if _, err := requireUser(ctx); err != nil {
+		return uuid.Nil, err
+	}
+
+	appId := reqctx.GetApplicationId(ctx)
+	if appId.IsNil() {
+		return uuid.Nil, proto.ErrPermissionDenied.WithCause(errors.New("token has no application"))
+	}
+
+	return appId, nil
+}
+
+// requireScope requires an authenticated user or API key granted the scope.
+func requireScope(ctx context.Context, scope string) error {
+	if err := requireAuth(ctx); err != nil {
+		return err
+	}
+
+	if apiKey := reqctx.GetApiKey(ctx); apiKey != nil && !slices.Contains(apiKey.Scopes, scope) {
+		return proto.ErrPermissionDenied.WithCause(fmt.Errorf("API key %s doesn't have %q scope", apiKey.Prefix, scope))
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package rpc
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jackc/pgconn"
+	"github.com/upper/db/v4"
+
+	"github.com/golang-cz/skeleton/data"
+	"github.com/golang-cz/skeleton/proto"
+)
+
+// Postgres error codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html.
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+)
+
+// MapError maps errors returned by handlers as they are, which the generated
+// server reports as WebrpcEndpoint errors, to the error catalogue by their
+// cause. Errors of the catalogue returned by handlers are kept, errors which
+// don't map are internal errors. The cause is kept for logs and tracing.
+func MapError(rpcErr *proto.WebRPCError) {
+	if rpcErr.Code != proto.ErrWebrpcEndpoint.Code {
+		return
+	}
+
+	catalogued := catalogueError(rpcErr.Unwrap())
+	rpcErr.Code = catalogued.Code
+	rpcErr.Name = catalogued.Name
+	rpcErr.Message = catalogued.Message
+	rpcErr.HTTPStatus = catalogued.HTTPStatus
+}
+
+func catalogueError(err error) proto.WebRPCError {
+	var (
+		validationErr *data.ValidationError
+		pgErr         *pgconn.PgError
+	)
+
+	switch {
+	case errors.As(err, &validationErr):
+		return proto.ErrInvalidArgument
+	case errors.Is(err, db.ErrNoMoreRows), errors.Is(err, sql.ErrNoRows):
+		return proto.ErrNotFound
+	case errors.Is(err, data.ErrEmailTaken):
+		return proto.ErrConflict
+	case errors.Is(err, data.ErrInvalidApiKey):
+		return proto.ErrUnauthenticated
+	case errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation:
+		return proto.ErrConflict
+	case errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation:
+		return proto.ErrInvalidArgument
+	default:
+		return proto.ErrWebrpcInternalError
+	}
+}
+
+// parseId parses id of a record given by the client.
+func parseId(field, id string) (uuid.UUID, error) {
+	uid, err := uuid.FromString(id)
+	if err != nil {
+		return uuid.Nil, data.Invalidf("%s: %w", field, err)
+	}
+
+	return uid, nil
+}
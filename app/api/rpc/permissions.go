@@ -0,0 +1,79 @@
+package rpc
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/golang-cz/skeleton/proto"
+)
+
+// Permissions users get by their roles, see the roles and role_permissions
+// tables.
+const (
+	PermUsersRead     = "users:read"
+	PermUsersWrite    = "users:write"
+	PermUsersDelete   = "users:delete"
+	PermRolesWrite    = "roles:write"
+	PermApiKeysManage = "apikeys:manage"
+)
+
+// methodPermissions are permissions users need to call RPC methods, by
+// "Interface.Method" of the proto interfaces. Every method must be listed,
+// methods listing none are open to everyone, they check authentication on
+// their own. API keys are limited by their scopes instead.
+var methodPermissions = map[string][]string{
+	"Users.GetUser":     {PermUsersRead},
+	"Users.ListUsers":   {PermUsersRead},
+	"Users.CreateUser":  {PermUsersWrite},
+	"Users.UpdateUser":  {PermUsersWrite},
+	"Users.DeleteUser":  {PermUsersDelete},
+	"Users.RestoreUser": {PermUsersDelete},
+
+	"ApiKeys.CreateApiKey": {PermApiKeysManage},
+	"ApiKeys.ListApiKeys":  {PermApiKeysManage},
+	"ApiKeys.RevokeApiKey": {PermApiKeysManage},
+
+	"Roles.GetMyPermissions": {},
+	"Roles.AssignRole":       {PermRolesWrite},
+	"Roles.UnassignRole":     {PermRolesWrite},
+}
+
+// MethodPermissions returns permissions required by RPC methods, by
+// "Service.Method" as in the URL path, eg. "Skeleton.GetUser". It fails if a
+// method isn't listed, so none is left unprotected by mistake, or if more
+// interfaces declare the same method, so it's unclear which permissions
+// apply.
+func MethodPermissions() (map[string][]string, error) {
+	service := reflect.TypeOf((*proto.Skeleton)(nil)).Elem()
+	interfaces := []reflect.Type{
+		reflect.TypeOf((*proto.Users)(nil)).Elem(),
+		reflect.TypeOf((*proto.ApiKeys)(nil)).Elem(),
+		reflect.TypeOf((*proto.Roles)(nil)).Elem(),
+	}
+
+	byMethod := map[string][]string{}
+	for _, t := range interfaces {
+		for i := 0; i < t.NumMethod(); i++ {
+			method := t.Method(i).Name
+			permissions, ok := methodPermissions[t.Name()+"."+method]
+			if !ok {
+				return nil, fmt.Errorf("permissions of %s.%s aren't defined", t.Name(), method)
+			}
+
+			key := service.Name() + "." + method
+			if _, ok := byMethod[key]; ok {
+				return nil, fmt.Errorf("method %s is declared by more interfaces", key)
+			}
+			byMethod[key] = permissions
+		}
+	}
+
+	if n := service.NumMethod(); len(byMethod) != n {
+		return nil, fmt.Errorf("permissions are defined for %d of %d methods", len(byMethod), n)
+	}
+	if len(methodPermissions) != len(byMethod) {
+		return nil, fmt.Errorf("permissions are defined for %d unknown methods", len(methodPermissions)-len(byMethod))
+	}
+
+	return byMethod, nil
+}
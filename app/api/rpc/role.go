@@ -0,0 +1,73 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/gofrs/uuid/v5"
+
+	"github.com/golang-cz/skeleton/internal/reqctx"
+	"github.com/golang-cz/skeleton/pkg/slogger"
+)
+
+func (r *Rpc) GetMyPermissions(ctx context.Context) ([]string, []string, error) {
+	userId, err := requireUser(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	grants, err := r.RBAC.Grants(ctx, userId, reqctx.GetApplicationId(ctx))
+	if err != nil {
+		return nil, nil, fmt.Errorf("get permissions: %w", err)
+	}
+
+	return grants.Roles, grants.Permissions, nil
+}
+
+func (r *Rpc) AssignRole(ctx context.Context, userId string, role string) error {
+	appId, err := requireApplication(ctx)
+	if err != nil {
+		return err
+	}
+
+	userUUID, err := parseId("userId", userId)
+	if err != nil {
+		return err
+	}
+
+	if err := r.DB.WithContext(ctx).Role.Assign(userUUID, appId, role); err != nil {
+		return fmt.Errorf("assign role: %w", err)
+	}
+	r.invalidatePermissions(ctx, userUUID)
+
+	return nil
+}
+
+func (r *Rpc) UnassignRole(ctx context.Context, userId string, role string) error {
+	appId, err := requireApplication(ctx)
+	if err != nil {
+		return err
+	}
+
+	userUUID, err := parseId("userId", userId)
+	if err != nil {
+		return err
+	}
+
+	if err := r.DB.WithContext(ctx).Role.Unassign(userUUID, appId, role); err != nil {
+		return fmt.Errorf("unassign role: %w", err)
+	}
+	r.invalidatePermissions(ctx, userUUID)
+
+	return nil
+}
+
+// invalidatePermissions drops cached permissions of the user. The role is
+// already changed, so failed broadcast is only logged, other replicas pick
+// the change up after the cache TTL.
+func (r *Rpc) invalidatePermissions(ctx context.Context, userId uuid.UUID) {
+	if err := r.RBAC.Invalidate(ctx, userId); err != nil {
+		slog.ErrorContext(ctx, slogger.ErrorCause(err).Error())
+	}
+}
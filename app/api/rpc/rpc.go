@@ -3,9 +3,11 @@ package rpc
 import (
 	"github.com/golang-cz/skeleton/config"
 	"github.com/golang-cz/skeleton/data"
+	"github.com/golang-cz/skeleton/pkg/rbac"
 )
 
 type Rpc struct {
 	Config *config.Config
 	DB     *data.Database
+	RBAC   *rbac.Authorizer
 }
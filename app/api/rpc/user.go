@@ -4,21 +4,190 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/gofrs/uuid/v5"
-
+	"github.com/golang-cz/skeleton/data"
 	"github.com/golang-cz/skeleton/proto"
 )
 
+const (
+	listUsersDefaultLimit = 50
+	listUsersMaxLimit     = 100
+	listUsersDefaultSort  = "createdAt"
+)
+
 func (r *Rpc) GetUser(ctx context.Context, userId string) (*proto.User, error) {
-	userUUUID, err := uuid.FromString(userId)
+	if err := requireScope(ctx, ScopeUsersRead); err != nil {
+		return nil, err
+	}
+
+	userUUUID, err := parseId("userId", userId)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := r.DB.WithContext(ctx).User.FindActiveById(userUUUID)
+	if err != nil {
+		return nil, fmt.Errorf("get user %v: %w", userUUUID, err)
+	}
+
+	return user.User, nil
+}
+
+func (r *Rpc) CreateUser(ctx context.Context, input *proto.UserInput) (*proto.User, error) {
+	if err := requireScope(ctx, ScopeUsersWrite); err != nil {
+		return nil, err
+	}
+
+	if input == nil {
+		return nil, data.Invalidf("input is required")
+	}
+
+	user := &data.User{
+		User: &proto.User{
+			Email:     input.Email,
+			Firstname: input.Firstname,
+			Lastname:  input.Lastname,
+		},
+	}
+	if err := r.DB.WithContext(ctx).User.Create(user); err != nil {
+		return nil, fmt.Errorf("create user: %w", err)
+	}
+
+	return user.User, nil
+}
+
+func (r *Rpc) UpdateUser(ctx context.Context, id string, input *proto.UserInput, fieldMask []string) (*proto.User, error) {
+	if err := requireScope(ctx, ScopeUsersWrite); err != nil {
+		return nil, err
+	}
+
+	userId, err := parseId("id", id)
+	if err != nil {
+		return nil, err
+	}
+	if input == nil {
+		return nil, data.Invalidf("input is required")
+	}
+	if len(fieldMask) == 0 {
+		return nil, data.Invalidf("fieldMask is required")
+	}
+
+	users := r.DB.WithContext(ctx).User
+
+	user, err := users.FindActiveById(userId)
+	if err != nil {
+		return nil, fmt.Errorf("get user %v: %w", userId, err)
+	}
+
+	for _, field := range fieldMask {
+		switch field {
+		case "email":
+			user.Email = input.Email
+		case "firstname":
+			user.Firstname = input.Firstname
+		case "lastname":
+			user.Lastname = input.Lastname
+		default:
+			return nil, data.Invalidf("unknown field %q in fieldMask", field)
+		}
+	}
+
+	if err := users.Update(user); err != nil {
+		return nil, fmt.Errorf("update user: %w", err)
+	}
+
+	return user.User, nil
+}
+
+func (r *Rpc) DeleteUser(ctx context.Context, id string) error {
+	if err := requireScope(ctx, ScopeUsersDelete); err != nil {
+		return err
+	}
+
+	userId, err := parseId("id", id)
 	if err != nil {
-		return nil, fmt.Errorf("get uuid from string: %w", err)
+		return err
 	}
 
-	user, err := r.DB.User.FindOne(userUUUID)
+	if err := r.DB.WithContext(ctx).User.SoftDelete(userId); err != nil {
+		return fmt.Errorf("delete user %v: %w", userId, err)
+	}
+
+	return nil
+}
+
+func (r *Rpc) RestoreUser(ctx context.Context, id string) (*proto.User, error) {
+	if err := requireScope(ctx, ScopeUsersDelete); err != nil {
+		return nil, err
+	}
+
+	userId, err := parseId("id", id)
 	if err != nil {
-		return nil, fmt.Errorf("get user: %w", err)
+		return nil, err
+	}
+
+	user, err := r.DB.WithContext(ctx).User.Restore(userId)
+	if err != nil {
+		return nil, fmt.Errorf("restore user %v: %w", userId, err)
 	}
 
 	return user.User, nil
 }
+
+func (r *Rpc) ListUsers(ctx context.Context, filter *proto.UsersFilter, sort string, cursor string, limit int) ([]*proto.User, string, error) {
+	if err := requireScope(ctx, ScopeUsersRead); err != nil {
+		return nil, "", err
+	}
+
+	if filter == nil {
+		filter = &proto.UsersFilter{}
+	}
+	switch filter.Status {
+	case "", data.UserStatusActive, data.UserStatusDeleted, data.UserStatusAll:
+	default:
+		return nil, "", data.Invalidf("unknown status %q", filter.Status)
+	}
+
+	if sort == "" {
+		sort = listUsersDefaultSort
+	}
+
+	switch {
+	case limit == 0:
+		limit = listUsersDefaultLimit
+	case limit < 0 || limit > listUsersMaxLimit:
+		return nil, "", data.Invalidf("limit must be between 1 and %d", listUsersMaxLimit)
+	}
+
+	query := data.UserListQuery{
+		Email:         filter.Email,
+		Status:        filter.Status,
+		CreatedAfter:  filter.CreatedAfter,
+		CreatedBefore: filter.CreatedBefore,
+		Sort:          sort,
+		Limit:         limit,
+	}
+	if cursor != "" {
+		after, err := data.DecodeUserCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		query.After = after
+	}
+
+	users, next, err := r.DB.WithContext(ctx).User.List(query)
+	if err != nil {
+		return nil, "", fmt.Errorf("list users: %w", err)
+	}
+
+	out := make([]*proto.User, len(users))
+	for i, user := range users {
+		out[i] = user.User
+	}
+
+	var nextCursor string
+	if next != nil {
+		nextCursor = next.Encode()
+	}
+
+	return out, nextCursor, nil
+}
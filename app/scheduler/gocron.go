@@ -7,6 +7,7 @@ import (
 	"github.com/go-co-op/gocron"
 
 	"github.com/golang-cz/skeleton/config"
+	"github.com/golang-cz/skeleton/pkg/metrics"
 	"github.com/golang-cz/skeleton/pkg/slogger"
 )
 
@@ -29,6 +30,8 @@ func gocronBeforeJobRuns(conf *config.Config) func(jobName string) {
 	}
 
 	return func(jobName string) {
+		metrics.JobStarted("gocron", jobName)
+		gocronStartSpan(jobName)
 		slog.LogAttrs(
 			context.Background(),
 			level,
@@ -46,6 +49,8 @@ func gocronWhenJobReturnsNoError(conf *config.Config) func(jobName string) {
 	}
 
 	return func(jobName string) {
+		metrics.JobFinished("gocron", jobName, nil)
+		gocronEndSpan(jobName, nil)
 		slog.LogAttrs(
 			context.Background(),
 			level,
@@ -57,6 +62,8 @@ func gocronWhenJobReturnsNoError(conf *config.Config) func(jobName string) {
 }
 
 func gocronWhenJobReturnsError(jobName string, err error) {
+	metrics.JobFinished("gocron", jobName, err)
+	gocronEndSpan(jobName, err)
 	slog.LogAttrs(
 		context.Background(),
 		slog.LevelError,
@@ -9,6 +9,8 @@ import (
 	"github.com/golang-cz/looper"
 )
 
+const idempotencyKeysBatchSize = 1000
+
 func (s *Scheduler) RegisterLooperJobs(ctx context.Context) error {
 	interval := time.Duration(s.Config.Looper.Interval)
 	waitAfterError := time.Duration(s.Config.Looper.WaitAfterError)
@@ -23,9 +25,26 @@ func (s *Scheduler) RegisterLooperJobs(ctx context.Context) error {
 			WaitAfterError:   waitAfterError,
 			WithLocker:       true,
 		},
+		{
+			Name:             "reencrypt-users",
+			JobFn:            s.reencryptUsers,
+			Timeout:          timeout,
+			WaitAfterSuccess: interval,
+			WaitAfterError:   waitAfterError,
+			WithLocker:       true,
+		},
+		{
+			Name:             "delete-expired-idempotency-keys",
+			JobFn:            s.deleteExpiredIdempotencyKeys,
+			Timeout:          timeout,
+			WaitAfterSuccess: time.Minute, // Expired keys are ignored anyway, no need to hurry.
+			WaitAfterError:   waitAfterError,
+			WithLocker:       true,
+		},
 	}
 
 	for _, j := range jobs {
+		j.JobFn = looperTraced(j)
 		err := s.looper.AddJob(ctx, j)
 		if err != nil {
 			return fmt.Errorf("add job to looper: %w", err)
@@ -46,3 +65,33 @@ func (s *Scheduler) testJob(ctx context.Context) error {
 	slog.Debug("test")
 	return nil
 }
+
+// reencryptUsers re-encrypts one batch of users after the active keyring key
+// got rotated.
+func (s *Scheduler) reencryptUsers(ctx context.Context) error {
+	count, err := s.DB.User.Reencrypt(ctx, s.Config.Encryption.ReencryptBatchSize)
+	if err != nil {
+		return fmt.Errorf("reencrypt users: %w", err)
+	}
+
+	if count > 0 {
+		slog.Info("users re-encrypted", slog.Int("count", count))
+	}
+
+	return nil
+}
+
+// deleteExpiredIdempotencyKeys deletes stored responses of idempotent
+// requests after their TTL. Keys stored in Redis expire on their own.
+func (s *Scheduler) deleteExpiredIdempotencyKeys(ctx context.Context) error {
+	count, err := s.DB.IdempotencyKey.DeleteExpired(ctx, idempotencyKeysBatchSize)
+	if err != nil {
+		return fmt.Errorf("delete expired idempotency keys: %w", err)
+	}
+
+	if count > 0 {
+		slog.Info("expired idempotency keys deleted", slog.Int("count", count))
+	}
+
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/golang-cz/skeleton/config"
+	"github.com/golang-cz/skeleton/pkg/metrics"
 	"github.com/golang-cz/skeleton/pkg/slogger"
 )
 
@@ -16,6 +17,7 @@ func looperBeforeJobRuns(conf *config.Config) func(jobName string) {
 	}
 
 	return func(jobName string) {
+		metrics.JobStarted("looper", jobName)
 		slog.LogAttrs(
 			context.Background(),
 			level,
@@ -33,6 +35,7 @@ func looperWhenJobReturnsNoError(conf *config.Config) func(jobName string, durat
 	}
 
 	return func(jobName string, duration time.Duration) {
+		metrics.JobFinished("looper", jobName, nil)
 		slog.LogAttrs(
 			context.Background(),
 			level,
@@ -45,6 +48,7 @@ func looperWhenJobReturnsNoError(conf *config.Config) func(jobName string, durat
 }
 
 func looperWhenJobReturnsError(jobName string, duration time.Duration, err error) {
+	metrics.JobFinished("looper", jobName, err)
 	ctx := context.Background()
 	slog.LogAttrs(
 		ctx,
@@ -2,9 +2,11 @@ package scheduler
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"runtime/debug"
 	"sync"
 	"time"
@@ -15,10 +17,12 @@ import (
 	"github.com/golang-cz/skeleton/config"
 	"github.com/golang-cz/skeleton/data"
 	"github.com/golang-cz/skeleton/pkg/events"
+	"github.com/golang-cz/skeleton/pkg/metrics"
 	"github.com/golang-cz/skeleton/pkg/nats"
 	"github.com/golang-cz/skeleton/pkg/pretty"
 	"github.com/golang-cz/skeleton/pkg/slogger"
 	"github.com/golang-cz/skeleton/pkg/status"
+	"github.com/golang-cz/skeleton/pkg/tracing"
 )
 
 type Scheduler struct {
@@ -28,6 +32,7 @@ type Scheduler struct {
 
 	gocron  *gocron.Scheduler
 	looper  *looper.Looper
+	metrics *http.Server
 	stopped chan struct{}
 }
 
@@ -51,6 +56,25 @@ func New(ctx context.Context, conf *config.Config) (*Scheduler, error) {
 		slog.Error(err.Error())
 	}
 
+	// Metrics
+	var metricsServer *http.Server
+	if conf.Metrics.Enabled {
+		if err := metrics.RegisterDB("main", database.Driver().(*sql.DB)); err != nil {
+			return nil, fmt.Errorf("failed to register DB metrics: %w", err)
+		}
+		if err := metrics.RegisterNATS(nats.Conn); err != nil {
+			return nil, fmt.Errorf("failed to register NATS metrics: %w", err)
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler())
+		metricsServer = &http.Server{
+			Addr:              conf.Metrics.SchedulerBindAddress,
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+	}
+
 	// Looper
 	looperConfig := looper.Config{
 		StartupTime: time.Second * 5,
@@ -69,8 +93,9 @@ func New(ctx context.Context, conf *config.Config) (*Scheduler, error) {
 		NatsClient: natsClient,
 		DB:         database,
 
-		gocron: cron,
-		looper: loop,
+		gocron:  cron,
+		looper:  loop,
+		metrics: metricsServer,
 
 		stopped: make(chan struct{}, 1),
 	}
@@ -99,6 +124,10 @@ func (s *Scheduler) RegisterJobs(ctx context.Context, conf *config.Config) (err
 }
 
 func (s *Scheduler) Run() {
+	if s.metrics != nil {
+		go s.serveMetrics()
+	}
+
 	s.looper.Start()
 	s.gocron.StartAsync()
 	s.printRegisteredJobsCount()
@@ -132,6 +161,27 @@ func (s *Scheduler) RunJob(jobNames ...string) (err error) {
 	return nil
 }
 
+func (s *Scheduler) serveMetrics() {
+	slog.Info(fmt.Sprintf("scheduler serving metrics at %v", s.metrics.Addr))
+
+	err := s.metrics.ListenAndServe()
+	if err != nil && err != http.ErrServerClosed {
+		err = fmt.Errorf("serve metrics: %w", err)
+		slog.Error(slogger.ErrorCause(err).Error())
+	}
+}
+
+func (s *Scheduler) stopMetrics() {
+	if s.metrics == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_ = s.metrics.Shutdown(ctx)
+}
+
 func (s *Scheduler) printRegisteredJobsCount() {
 	slog.Info("scheduler started jobs",
 		slog.Any("gocron jobs", len(s.gocron.Jobs())),
@@ -216,6 +266,7 @@ func (s *Scheduler) Stop() {
 		{"nats", s.NatsClient.Close},
 		{"gocron", s.gocron.Stop},
 		{"looper", s.looper.Stop},
+		{"metrics", s.stopMetrics},
 	}
 
 	var wg sync.WaitGroup
@@ -230,6 +281,12 @@ func (s *Scheduler) Stop() {
 		slog.Error("close db connection", slog.Any("err", err))
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tracing.Shutdown(ctx); err != nil {
+		slog.Error(slogger.ErrorCause(err).Error())
+	}
+
 	slog.Info("all scheduler jobs stopped")
 }
 
@@ -0,0 +1,62 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+
+	"github.com/golang-cz/looper"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/golang-cz/skeleton/pkg/tracing"
+)
+
+// startJobSpan starts root span of a job run, every run is a trace of its own.
+func startJobSpan(ctx context.Context, runner, jobName string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, jobName,
+		trace.WithNewRoot(),
+		trace.WithAttributes(
+			attribute.String("job.runner", runner),
+			attribute.String("job.name", jobName),
+		),
+	)
+}
+
+// looperTraced wraps looper job function, so queries, NATS messages etc. of
+// the job run are part of its trace.
+func looperTraced(job *looper.Job) looper.JobFn {
+	jobFn := job.JobFn
+
+	return func(ctx context.Context) error {
+		ctx, span := startJobSpan(ctx, "looper", job.Name)
+		defer span.End()
+
+		err := jobFn(ctx)
+		if err != nil {
+			tracing.RecordError(ctx, err)
+		}
+		return err
+	}
+}
+
+// Spans of gocron jobs running now by job name. Gocron jobs get no context,
+// so their spans are started and ended by the event listeners.
+var gocronSpans sync.Map
+
+func gocronStartSpan(jobName string) {
+	_, span := startJobSpan(context.Background(), "gocron", jobName)
+	gocronSpans.Store(jobName, span)
+}
+
+func gocronEndSpan(jobName string, err error) {
+	s, ok := gocronSpans.LoadAndDelete(jobName)
+	if !ok {
+		return
+	}
+
+	span := s.(trace.Span)
+	if err != nil {
+		tracing.RecordError(trace.ContextWithSpan(context.Background(), span), err)
+	}
+	span.End()
+}
@@ -47,6 +47,9 @@ func main() {
 	go func() {
 		sig := <-sigs
 		slog.Info("received signal", "signal", sig)
+		if sig == syscall.SIGTERM {
+			app.Drain()
+		}
 		app.Stop(10 * time.Second)
 	}()
 
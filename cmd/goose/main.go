@@ -1,10 +1,13 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/golang-cz/skeleton/config"
 	"github.com/golang-cz/skeleton/data/migration"
@@ -41,12 +44,222 @@ func main() {
 		log.Fatalf("setup app: %v", err)
 	}
 
+	if args[0] == "lint" {
+		lint(conf.Goose.Dir, args[1:])
+		return
+	}
+
+	if args[0] == "schema" {
+		schema(conf, args[1:])
+		return
+	}
+
+	if args[0] == "fix" {
+		fix(conf, args[1:])
+		return
+	}
+
+	if args[0] == "plan" {
+		plan(conf)
+		return
+	}
+
+	if args[0] == "up" && len(args) > 1 {
+		dryRun(conf, args[1:])
+		return
+	}
+
 	err = migration.RunMigrations(args, conf)
 	if err != nil {
 		log.Fatal(fmt.Errorf("goose migration: %w", err))
 	}
 }
 
+// lint prints findings of the migration linter and exits with non-zero code
+// if there are any, so it can be used in git hooks and CI.
+func lint(dir string, files []string) {
+	findings, err := migration.Lint(dir, files...)
+	if err != nil {
+		log.Fatal(fmt.Errorf("lint migrations: %w", err))
+	}
+
+	for _, f := range findings {
+		fmt.Println(f)
+	}
+
+	if len(findings) > 0 {
+		fmt.Printf("\n%d issue(s) found, annotate intended statements with -- lint:ignore RULE REASON\n", len(findings))
+		os.Exit(1)
+	}
+}
+
+// schema compares schema produced by migrations with the committed schema
+// file and exits with non-zero code if they differ. With --write flag the
+// file is regenerated instead.
+func schema(conf *config.Config, args []string) {
+	schemaFlags := flag.NewFlagSet("schema", flag.ExitOnError)
+	write := schemaFlags.Bool("write", false, "regenerate schema file from migrations")
+	schemaFlags.Parse(args)
+
+	schemaFile := "db/schema.sql"
+	if schemaFlags.NArg() > 0 {
+		schemaFile = schemaFlags.Arg(0)
+	}
+
+	ctx := context.Background()
+
+	migrated, err := migration.DumpSchema(ctx, conf)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *write {
+		if err := os.WriteFile(schemaFile, []byte(migrated.SQL()), 0o644); err != nil {
+			log.Fatal(fmt.Errorf("write schema: %w", err))
+		}
+		fmt.Printf("%s regenerated\n", schemaFile)
+		return
+	}
+
+	committed, err := migration.LoadSchemaFile(ctx, conf, schemaFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	diff := migration.DiffSchemas(committed, migrated)
+	if len(diff) == 0 {
+		fmt.Printf("%s is up to date\n", schemaFile)
+		return
+	}
+
+	fmt.Printf("--- %s\n+++ migrations\n", schemaFile)
+	for _, line := range diff {
+		fmt.Println(line)
+	}
+	fmt.Printf("\n%s is out of date, run: goose schema --write\n", schemaFile)
+	os.Exit(1)
+}
+
+// fix renames timestamped migrations to sequential versions. Local DB is
+// updated first, so already applied migrations aren't run again and a failed
+// update leaves the files untouched.
+func fix(conf *config.Config, args []string) {
+	fixFlags := flag.NewFlagSet("fix", flag.ExitOnError)
+	dry := fixFlags.Bool("dry-run", false, "print renames without doing them")
+	fixFlags.Parse(args)
+
+	renames, err := migration.Fix(conf.Goose.Dir)
+	if err != nil {
+		log.Fatal(fmt.Errorf("fix migrations: %w", err))
+	}
+
+	if len(renames) == 0 {
+		fmt.Println("No timestamped migrations")
+		return
+	}
+
+	for _, r := range renames {
+		fmt.Println(r)
+	}
+
+	if *dry {
+		return
+	}
+
+	if conf.Environment.IsLocal() {
+		if err := migration.FixDBVersions(context.Background(), conf.DB, renames); err != nil {
+			log.Fatal(fmt.Errorf("fix local DB versions: %w", err))
+		}
+		fmt.Println("Local DB versions updated")
+	}
+
+	if err := migration.RenameFiles(conf.Goose.Dir, renames); err != nil {
+		log.Fatal(fmt.Errorf("fix migrations: %w, finish the renames above by hand", err))
+	}
+}
+
+// plan prints pending migrations with their statements and heavy lock
+// warnings without running anything.
+func plan(conf *config.Config) {
+	p, err := migration.Plan(context.Background(), conf)
+	if err != nil {
+		log.Fatal(fmt.Errorf("plan migrations: %w", err))
+	}
+
+	printPlan(p, false)
+}
+
+// dryRun runs pending migrations in a transaction which is rolled back and
+// prints the plan with observed timing and locks.
+func dryRun(conf *config.Config, args []string) {
+	upFlags := flag.NewFlagSet("up", flag.ExitOnError)
+	dry := upFlags.Bool("dry-run", false, "run pending migrations in a transaction which is rolled back")
+	lockTimeout := upFlags.Duration("lock-timeout", 5*time.Second, "max time a statement waits for a lock during dry run")
+	upFlags.Parse(args)
+
+	if !*dry {
+		log.Fatalf("unexpected arguments for up: %v", args)
+	}
+
+	p, err := migration.DryRun(context.Background(), conf, *lockTimeout)
+	if p != nil {
+		printPlan(p, true)
+	}
+	if err != nil {
+		log.Fatal(fmt.Errorf("dry run: %w", err))
+	}
+}
+
+func printPlan(p *migration.MigrationPlan, executed bool) {
+	fmt.Printf("Current version: %d\n", p.CurrentVersion)
+	fmt.Printf("Pending migrations: %d\n", len(p.Migrations))
+
+	var warnings int
+	for _, m := range p.Migrations {
+		fmt.Printf("\n%s", m.Source)
+		switch {
+		case m.OutOfOrder:
+			fmt.Print(" (out of order, goose up refuses to apply it)")
+		case m.NoTx && executed:
+			fmt.Print(" (NO TRANSACTION, not executed)")
+		case m.NoTx:
+			fmt.Print(" (NO TRANSACTION)")
+		case m.Executed:
+			fmt.Printf(" (%s)", m.Duration.Round(time.Millisecond))
+		}
+		fmt.Println()
+
+		for _, step := range m.Steps {
+			if m.Go {
+				fmt.Println("\tGo migration")
+			} else {
+				fmt.Printf("%5d\t%s;\n", step.Line, strings.ReplaceAll(step.SQL, "\n", "\n\t"))
+			}
+
+			if step.Warning != "" {
+				warnings++
+				fmt.Printf("\tWARNING: %s\n", step.Warning)
+			}
+			if step.Duration > 0 || step.Err != nil {
+				fmt.Printf("\t-> %s, lock wait ~%s\n", step.Duration.Round(time.Millisecond), step.LockWait)
+			}
+			for _, lock := range step.Locks {
+				fmt.Printf("\t-> acquired %s\n", lock)
+			}
+			if step.Err != nil {
+				fmt.Printf("\t-> ERROR: %v\n", step.Err)
+			}
+		}
+	}
+
+	if warnings > 0 {
+		fmt.Printf("\n%d statement(s) take heavy locks, consider running them off-peak\n", warnings)
+	}
+	if executed {
+		fmt.Println("\nDry run, all changes were rolled back.")
+	}
+}
+
 func usage() {
 	fmt.Print(usagePrefix)
 	flags.PrintDefaults()
@@ -63,9 +276,14 @@ Options:
 Commands:
 	create MIGRATION_NAME [go|sql] Create new migration	
 	up         Migrate the DB to the most recent version available
+	up --dry-run [--lock-timeout=5s] Run pending migrations in a rolled back transaction, report timing and locks
+	plan       Print pending migrations with their SQL and heavy lock warnings
 	down       Roll back the version by 1
 	redo       Re-run the latest migration
 	status     Dump the migration status for the current DB
 	dbversion  Print the current version of the database
+	fix [--dry-run] Rename timestamped migrations to sequential versions, update local DB
+	lint [FILES...] Check migrations for statements dangerous on production DB
+	schema [--write] [FILE] Compare FILE (default db/schema.sql) with schema produced by migrations
 `
 )
@@ -8,22 +8,74 @@ import (
 )
 
 type Config struct {
-	AllowedOrigins           []string    `toml:"allowed_origins"`
 	DisableHandlerSuccessLog bool        `toml:"disable_handler_success_log"`
 	Environment              Environment `toml:"environment"`
 	Port                     string      `toml:"bind_address"`
 	BaseUrl                  string      `toml:"base_url"`
 
 	// Subgroups
-	AWS        AWS        `toml:"aws"`
-	DB         DB         `toml:"db"`
-	Debug      Debug      `toml:"debug"`
-	StatusPage StatusPage `toml:"status_page"`
-	Looper     Looper     `toml:"looper"`
-	Goose      Goose      `toml:"goose"`
-	NATS       NATS       `toml:"nats"`
-	Redis      Redis      `toml:"redis"`
-	Sentry     Sentry     `toml:"sentry"`
+	Admin       Admin       `toml:"admin"`
+	Auth        Auth        `toml:"auth"`
+	AWS         AWS         `toml:"aws"`
+	DB          DB          `toml:"db"`
+	Debug       Debug       `toml:"debug"`
+	Encryption  Encryption  `toml:"encryption"`
+	StatusPage  StatusPage  `toml:"status_page"`
+	Looper      Looper      `toml:"looper"`
+	Metrics     Metrics     `toml:"metrics"`
+	Goose       Goose       `toml:"goose"`
+	HTTP        HTTP        `toml:"http"`
+	Idempotency Idempotency `toml:"idempotency"`
+	Maintenance Maintenance `toml:"maintenance"`
+	NATS        NATS        `toml:"nats"`
+	RBAC        RBAC        `toml:"rbac"`
+	RateLimit   RateLimit   `toml:"rate_limit"`
+	Redis       Redis       `toml:"redis"`
+	Sentry      Sentry      `toml:"sentry"`
+	Static      Static      `toml:"static"`
+	Tracing     Tracing     `toml:"tracing"`
+}
+
+// Admin configures the admin router with pprof, build info, config dump,
+// log level control and Sentry test trigger. Requests must come from an
+// allowed IP and carry the credential in HTTP basic auth.
+type Admin struct {
+	Enabled bool `toml:"enabled"`
+	// Own listener, eg. "127.0.0.1:7099". Empty mounts the router on
+	// /_admin of the API.
+	BindAddress string `toml:"bind_address"`
+	// Client IPs or CIDRs allowed, eg. "10.0.0.0/8". On the own listener
+	// it's the peer address, on the API the one set by RealIP middleware.
+	AllowedIPs []string `toml:"allowed_ips"`
+	Username   string   `toml:"username"`
+	Password   string   `toml:"password" secret:"true"`
+	// Block and mutex profiling, see runtime.SetBlockProfileRate and
+	// runtime.SetMutexProfileFraction. Zero keeps them disabled.
+	BlockProfileRate     int `toml:"block_profile_rate"`
+	MutexProfileFraction int `toml:"mutex_profile_fraction"`
+}
+
+// Auth configures verification of JWT bearer tokens.
+type Auth struct {
+	Issuer   string `toml:"issuer"`
+	Audience string `toml:"audience"`
+	// Shared secret of HS256 tokens.
+	HS256Secret string `toml:"hs256_secret" secret:"true"`
+	// JWKS with RS256/EdDSA public keys, either a file or an URL.
+	JWKSFile string `toml:"jwks_file"`
+	JWKSURL  string `toml:"jwks_url"`
+	// How often JWKS is reloaded to pick up rotated keys.
+	JWKSRefreshInterval Duration `toml:"jwks_refresh_interval"`
+	// Allowed clock skew when validating exp/nbf/iat.
+	Leeway Duration `toml:"leeway"`
+}
+
+// JWKSSource returns JWKS URL, or file path if URL isn't set.
+func (a Auth) JWKSSource() string {
+	if a.JWKSURL != "" {
+		return a.JWKSURL
+	}
+	return a.JWKSFile
 }
 
 type Debug struct {
@@ -73,9 +125,20 @@ type DB struct {
 	MaxOpenConns      int    `toml:"max_open_conns"`
 	ReadOnly          bool   `toml:"read_only"`
 	Username          string `toml:"username"`
-	Password          string `toml:"password"`
+	Password          string `toml:"password" secret:"true"`
 	SSLMode           string `toml:"sslmode"`
 	ReportQueryErrors bool   `toml:"report_query_errors"`
+	// Tag SQL queries with request id in a comment. Every query text is then
+	// unique, so pgx can't reuse its prepared statements.
+	QueryComments bool `toml:"query_comments"`
+}
+
+// Encryption configures application-level encryption of PII columns.
+type Encryption struct {
+	// Path to keyring file with encryption keys.
+	Keyring string `toml:"keyring"`
+	// Number of rows re-encrypted by one scheduler job run after active key rotation.
+	ReencryptBatchSize int `toml:"reencrypt_batch_size"`
 }
 
 type StatusPage struct {
@@ -92,6 +155,123 @@ type Looper struct {
 type Goose struct {
 	Dir    string `toml:"dir"`
 	Driver string `toml:"driver"`
+	// Max time to wait for another migration run to finish.
+	LockTimeout Duration `toml:"lock_timeout"`
+	// API refuses to start when the DB schema is older than the binary expects.
+	RequireCurrentSchema bool `toml:"require_current_schema"`
+}
+
+// HTTP configures the API HTTP server.
+type HTTP struct {
+	// How long /_api/readyz fails on SIGTERM before the server stops
+	// accepting connections, so load balancers stop routing requests first.
+	DrainPeriod Duration `toml:"drain_period"`
+	// Serve HTTP/2 without TLS (h2c), eg. for internal traffic behind a
+	// proxy. With TLS, HTTP/2 is always served.
+	H2C bool `toml:"h2c"`
+
+	TLS         TLS         `toml:"tls"`
+	Compression Compression `toml:"compression"`
+	Security    Security    `toml:"security"`
+	// CORS policies of route groups: "rpc" for /_api/rpc, "status" for
+	// /_api/ping, /_api/livez and /_api/readyz and "admin" for /_admin.
+	// Groups without a policy don't allow cross-origin requests.
+	CORS map[string]CORS `toml:"cors"`
+}
+
+// TLS configures HTTPS of the API server. Certificate files are reloaded
+// when they change, so renewed certificates are used without a restart.
+type TLS struct {
+	Enabled  bool   `toml:"enabled"`
+	CertFile string `toml:"cert_file"`
+	KeyFile  string `toml:"key_file"`
+	// Minimum version, "1.2" or "1.3". Empty means "1.2".
+	MinVersion string `toml:"min_version"`
+	// CA bundle verifying client certificates (mTLS).
+	ClientCAFile string `toml:"client_ca_file"`
+	// Client certificate policy, one of "none", "request", "verify_if_given"
+	// and "require". Empty means "require" with client CA, "none" without.
+	ClientAuth string `toml:"client_auth"`
+	// How often the files are checked for changes, 10s by default.
+	ReloadInterval Duration `toml:"reload_interval"`
+}
+
+// Security configures security headers sent with every response. Empty
+// values aren't sent.
+type Security struct {
+	// Strict-Transport-Security max-age, zero disables HSTS.
+	HSTSMaxAge            Duration `toml:"hsts_max_age"`
+	HSTSIncludeSubdomains bool     `toml:"hsts_include_subdomains"`
+	HSTSPreload           bool     `toml:"hsts_preload"`
+	// X-Content-Type-Options: nosniff
+	NoSniff               bool   `toml:"nosniff"`
+	ContentSecurityPolicy string `toml:"content_security_policy"`
+	FrameOptions          string `toml:"frame_options"`
+	ReferrerPolicy        string `toml:"referrer_policy"`
+	PermissionsPolicy     string `toml:"permissions_policy"`
+}
+
+// CORS is a cross-origin resource sharing policy of a route group.
+type CORS struct {
+	// Origins like "https://app.golang.cz", "https://*.golang.cz" for any
+	// subdomain or "*" for any origin.
+	AllowedOrigins   []string `toml:"allowed_origins"`
+	AllowedMethods   []string `toml:"allowed_methods"`
+	AllowedHeaders   []string `toml:"allowed_headers"`
+	ExposedHeaders   []string `toml:"exposed_headers"`
+	AllowCredentials bool     `toml:"allow_credentials"`
+	// How long browsers cache preflight responses.
+	MaxAge Duration `toml:"max_age"`
+}
+
+// Compression configures compression of HTTP responses. Encoding is
+// negotiated from Accept-Encoding header.
+type Compression struct {
+	Enabled bool `toml:"enabled"`
+	// Supported encodings in order of preference, of "zstd", "br" and "gzip".
+	Encodings []string `toml:"encodings"`
+	// Responses with fewer bytes are sent uncompressed.
+	MinSize int `toml:"min_size"`
+	// Compressed media types, eg. "application/json" or "text/*". Anything
+	// else, eg. images or archives, is sent as is.
+	ContentTypes []string `toml:"content_types"`
+}
+
+// Idempotency configures handling of RPC calls sent with Idempotency-Key
+// header.
+type Idempotency struct {
+	Enabled bool `toml:"enabled"`
+	// Where the responses are stored, "postgres" or "redis".
+	Backend string `toml:"backend"`
+	// How long retries get the stored response.
+	TTL Duration `toml:"ttl"`
+	// How long a request in progress holds its key, in case it never finishes.
+	LockTimeout Duration `toml:"lock_timeout"`
+	// How long a concurrent duplicate waits for the first request to finish
+	// before getting 409 Conflict. Zero means it doesn't wait.
+	Wait Duration `toml:"wait"`
+}
+
+// Maintenance configures maintenance mode. While on, the API refuses
+// requests with 503, except admin router, health probes and allowlisted
+// paths and IPs.
+type Maintenance struct {
+	// Path prefixes served during maintenance, eg. "/_api/rpc/Skeleton/GetStatus".
+	AllowedPaths []string `toml:"allowed_paths"`
+	// Client IPs or CIDRs served during maintenance, eg. of admins.
+	AllowedIPs []string `toml:"allowed_ips"`
+	// Retry-After sent when maintenance has no ETA or it has passed.
+	RetryAfter Duration `toml:"retry_after"`
+	// How often the state is reloaded from DB, in case a NATS broadcast is
+	// missed. Zero disables reloading.
+	SyncInterval Duration `toml:"sync_interval"`
+}
+
+// Metrics configures Prometheus metrics. API serves them on /metrics.
+type Metrics struct {
+	Enabled bool `toml:"enabled"`
+	// Scheduler has no HTTP server, it serves /metrics on this address.
+	SchedulerBindAddress string `toml:"scheduler_bind_address"`
 }
 
 type NATS struct {
@@ -99,12 +279,81 @@ type NATS struct {
 	Cluster string `toml:"cluster"`
 }
 
+// RBAC configures caching of permissions users have by their roles. Role
+// changes invalidate the cache on all replicas over NATS, TTL bounds
+// staleness if a broadcast is missed.
+type RBAC struct {
+	CacheTTL Duration `toml:"cache_ttl"`
+	// Max users cached, per replica.
+	CacheSize int `toml:"cache_size"`
+}
+
+// RateLimit configures token bucket rate limiting of API requests. Every
+// request is checked against all rules matching its path.
+type RateLimit struct {
+	Enabled bool `toml:"enabled"`
+	// Where the buckets are kept, "memory" for a single instance or "redis"
+	// for limits shared by all instances.
+	Backend string          `toml:"backend"`
+	Rules   []RateLimitRule `toml:"rules"`
+}
+
+type RateLimitRule struct {
+	// Unique name, part of the bucket key.
+	Name string `toml:"name"`
+	// Request path, trailing * matches any suffix, eg. "/_api/rpc/*".
+	Path string `toml:"path"`
+	// What the buckets are keyed by, any of "ip", "user", "api_key" and
+	// "method" (RPC method). Anonymous requests fall back to "ip". The IP is
+	// the connection peer, ie. the nearest proxy, headers like
+	// X-Forwarded-For are ignored as clients can set them.
+	Key []string `toml:"key"`
+	// Requests allowed per period, on average.
+	Requests int      `toml:"requests"`
+	Period   Duration `toml:"period"`
+	// Max requests allowed at once, defaults to requests.
+	Burst int `toml:"burst"`
+}
+
 type Redis struct {
 	Host string `toml:"host"`
 }
 
 type Sentry struct {
-	DSN string `toml:"dsn"`
+	DSN string `toml:"dsn" secret:"true"`
+}
+
+// Static configures serving of the frontend embedded in the binary, see
+// the web package. Paths not matching a file fall back to index.html, so
+// the frontend can do its own routing.
+type Static struct {
+	Enabled bool `toml:"enabled"`
+	// Path the frontend is served at, eg. "/" or "/admin/". Paths starting
+	// with "/_" are reserved for the API.
+	MountPath string `toml:"mount_path"`
+	// Dev serves files from Dir instead of the embedded ones and reloads
+	// the browser when they change.
+	Dev bool   `toml:"dev"`
+	Dir string `toml:"dir"`
+	// Path prefixes of assets with content hash in their names, eg.
+	// "/assets/". They are cached by browsers for a year.
+	ImmutablePaths []string `toml:"immutable_paths"`
+}
+
+// Tracing configures OpenTelemetry tracing.
+type Tracing struct {
+	Enabled bool `toml:"enabled"`
+	// Where spans are exported: "otlp", "stdout" or "file".
+	Exporter string `toml:"exporter"`
+	// OTLP/HTTP traces endpoint, eg. "http://localhost:4318/v1/traces".
+	Endpoint string `toml:"endpoint"`
+	// Headers sent to the OTLP endpoint, eg. for authentication.
+	Headers map[string]string `toml:"headers" secret:"true"`
+	// File the "file" exporter appends spans to, one JSON per line.
+	File string `toml:"file"`
+	// Fraction of traces started here that are sampled. Traces continued
+	// from callers follow their sampling decision.
+	SampleRatio float64 `toml:"sample_ratio"`
 }
 
 func NewFromReader(confFile string) (*Config, error) {
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const redacted = "[redacted]"
+
+// Redacted returns the config as a map keyed like the config file, with
+// values of fields tagged `secret:"true"` redacted. Use it for config dumps.
+func (c *Config) Redacted() map[string]interface{} {
+	return redact(reflect.ValueOf(*c)).(map[string]interface{})
+}
+
+func redact(v reflect.Value) interface{} {
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		return m
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return redact(v.Elem())
+
+	case reflect.Struct:
+		t := v.Type()
+		fields := make(map[string]interface{}, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+
+			key, _, _ := strings.Cut(f.Tag.Get("toml"), ",")
+			switch key {
+			case "-":
+				continue
+			case "":
+				key = f.Name
+			}
+
+			if f.Tag.Get("secret") == "true" && !v.Field(i).IsZero() {
+				fields[key] = redacted
+				continue
+			}
+			fields[key] = redact(v.Field(i))
+		}
+		return fields
+
+	case reflect.Slice, reflect.Array:
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = redact(v.Index(i))
+		}
+		return items
+
+	case reflect.Map:
+		items := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			items[fmt.Sprint(iter.Key().Interface())] = redact(iter.Value())
+		}
+		return items
+	}
+
+	return v.Interface()
+}
@@ -0,0 +1,232 @@
+package data
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/upper/db/v4"
+
+	"github.com/golang-cz/skeleton/internal/guuid"
+	"github.com/golang-cz/skeleton/pkg/utc"
+	"github.com/golang-cz/skeleton/proto"
+)
+
+// API key format: skel_<prefix>_<secret>. The prefix is stored in plaintext
+// for lookup, the whole key only as SHA-256 hash. Keys are random, so a salt
+// or slow hash isn't needed.
+const (
+	apiKeyPrefix            = "skel"
+	apiKeyPrefixSize        = 4  // Bytes, hex encoded.
+	apiKeySecretSize        = 32 // Bytes, base64 encoded.
+	apiKeyLastUsedPrecision = time.Minute
+)
+
+var ErrInvalidApiKey = errors.New("invalid API key")
+
+type ApiKey struct {
+	*proto.ApiKey
+
+	KeyHash       []byte     `json:"-" db:"key_hash"`
+	CreatedBy     *uuid.UUID `json:"-" db:"created_by"`
+	ApplicationId *uuid.UUID `json:"-" db:"application_id"` // Application whose permissions of the creator limit the key.
+	UpdatedAt     time.Time  `json:"-" db:"updated_at"`
+}
+
+type ApiKeyStore struct {
+	db.Collection
+}
+
+// Interface checks
+var _ = interface {
+	db.Record
+	db.BeforeCreateHook
+	db.BeforeUpdateHook
+}(&ApiKey{})
+
+var _ = interface {
+	db.Store
+}(&ApiKeyStore{})
+
+func ApiKeys(sess db.Session) *ApiKeyStore {
+	return &ApiKeyStore{sess.Collection("api_keys")}
+}
+
+func (k *ApiKey) Store(sess db.Session) db.Store {
+	return ApiKeys(sess)
+}
+
+func (k *ApiKey) BeforeCreate(sess db.Session) error {
+	if err := k.Validate(); err != nil {
+		return fmt.Errorf("api key is not valid: %w", err)
+	}
+
+	k.CreatedAt = utc.Now()
+	k.UpdatedAt = k.CreatedAt
+
+	return nil
+}
+
+func (k *ApiKey) BeforeUpdate(sess db.Session) error {
+	if err := k.Validate(); err != nil {
+		return fmt.Errorf("api key is not valid: %w", err)
+	}
+
+	k.UpdatedAt = utc.Now()
+
+	return nil
+}
+
+func (k *ApiKey) Validate() error {
+	if k.Name == "" {
+		return Invalidf("name is required")
+	}
+	if len(k.KeyHash) != sha256.Size {
+		return errors.New("key hash is required")
+	}
+	return nil
+}
+
+// IsActive reports whether the key is neither revoked nor expired.
+func (k *ApiKey) IsActive() bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || k.ExpiresAt.After(utc.Now()))
+}
+
+// HasScope reports whether the key was granted given scope.
+func (k *ApiKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+// NewApiKey generates a new API key. The returned key is the only place
+// where the plaintext key is available.
+func NewApiKey(name string, scopes []string, expiresAt *time.Time, createdBy, applicationId *uuid.UUID) (*ApiKey, string, error) {
+	prefix := make([]byte, apiKeyPrefixSize)
+	secret := make([]byte, apiKeySecretSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, "", fmt.Errorf("generate prefix: %w", err)
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", fmt.Errorf("generate secret: %w", err)
+	}
+
+	apiKey := &ApiKey{
+		ApiKey: &proto.ApiKey{
+			ID:        guuid.NewV7(),
+			Name:      name,
+			Prefix:    hex.EncodeToString(prefix),
+			Scopes:    scopes,
+			ExpiresAt: expiresAt,
+		},
+		CreatedBy:     createdBy,
+		ApplicationId: applicationId,
+	}
+
+	key := fmt.Sprintf("%s_%s_%s", apiKeyPrefix, apiKey.Prefix, base64.RawURLEncoding.EncodeToString(secret))
+	apiKey.KeyHash = hashApiKey(key)
+
+	return apiKey, key, nil
+}
+
+func hashApiKey(key string) []byte {
+	hash := sha256.Sum256([]byte(key))
+	return hash[:]
+}
+
+func (s ApiKeyStore) Find(conds ...interface{}) db.Result {
+	return s.Collection.Find(conds...)
+}
+
+func (s ApiKeyStore) FindOne(conds ...interface{}) (apiKey *ApiKey, err error) {
+	if err = s.Find(conds...).One(&apiKey); err != nil {
+		return nil, fmt.Errorf("get first record: %w", err)
+	}
+
+	return apiKey, nil
+}
+
+func (s ApiKeyStore) FindById(id uuid.UUID, conds ...interface{}) (apiKey *ApiKey, err error) {
+	return s.FindOne(append([]interface{}{db.Cond{"id": id}}, conds...)...)
+}
+
+func (s ApiKeyStore) FindAll(conds ...interface{}) (apiKeys []*ApiKey, err error) {
+	if err = s.Find(conds...).OrderBy("-created_at").All(&apiKeys); err != nil {
+		return nil, fmt.Errorf("get all records: %w", err)
+	}
+
+	return apiKeys, nil
+}
+
+// Authenticate finds active API key matching given plaintext key and
+// records its usage. It returns ErrInvalidApiKey for unknown, revoked and
+// expired keys.
+func (s ApiKeyStore) Authenticate(key string) (*ApiKey, error) {
+	prefix, _, ok := parseApiKey(key)
+	if !ok {
+		return nil, ErrInvalidApiKey
+	}
+
+	apiKey, err := s.FindOne(db.Cond{"prefix": prefix})
+	if err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return nil, ErrInvalidApiKey
+		}
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare(apiKey.KeyHash, hashApiKey(key)) != 1 || !apiKey.IsActive() {
+		return nil, ErrInvalidApiKey
+	}
+
+	// Don't write on every request, minute precision is good enough.
+	now := utc.Now()
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > apiKeyLastUsedPrecision {
+		apiKey.LastUsedAt = &now
+		if err := s.Find(db.Cond{"id": apiKey.ID}).Update(map[string]interface{}{"last_used_at": now}); err != nil {
+			return nil, fmt.Errorf("update last used: %w", err)
+		}
+	}
+
+	return apiKey, nil
+}
+
+// FindAllByCreator returns keys created by given user, newest first.
+func (s ApiKeyStore) FindAllByCreator(createdBy uuid.UUID) ([]*ApiKey, error) {
+	return s.FindAll(db.Cond{"created_by": createdBy})
+}
+
+// Revoke revokes the key created by given user, it can't be used anymore.
+// Keys of other users are reported as not found.
+func (s ApiKeyStore) Revoke(id, createdBy uuid.UUID) error {
+	res := s.Find(db.Cond{"id": id, "created_by": createdBy, "revoked_at": db.IsNull()})
+
+	count, err := res.Count()
+	if err != nil {
+		return fmt.Errorf("count: %w", err)
+	}
+	if count == 0 {
+		return fmt.Errorf("revoke %v: %w", id, db.ErrNoMoreRows)
+	}
+
+	now := utc.Now()
+	if err := res.Update(map[string]interface{}{"revoked_at": now, "updated_at": now}); err != nil {
+		return fmt.Errorf("revoke %v: %w", id, err)
+	}
+
+	return nil
+}
+
+func parseApiKey(key string) (prefix, secret string, ok bool) {
+	parts := strings.SplitN(key, "_", 3) // Secret may contain "_" too.
+	if len(parts) != 3 || parts[0] != apiKeyPrefix || len(parts[1]) != hex.EncodedLen(apiKeyPrefixSize) {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
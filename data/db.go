@@ -1,6 +1,8 @@
 package data
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"log"
@@ -14,7 +16,11 @@ import (
 type Database struct {
 	db.Session
 
-	User UserStore
+	ApiKey         ApiKeyStore
+	IdempotencyKey IdempotencyKeyStore
+	Maintenance    MaintenanceStore
+	Role           RoleStore
+	User           UserStore
 }
 
 func NewDBSession(conf config.DB) (*Database, error) {
@@ -40,7 +46,7 @@ func NewDBSession(conf config.DB) (*Database, error) {
 		connURL.Options["connect_timeout"] = fmt.Sprintf("%d", conf.ConnectionTimeout)
 	}
 
-	dbSession, err := postgresql.Open(connURL)
+	dbSession, err := open(connURL, conf.QueryComments)
 	if err != nil {
 		return nil, fmt.Errorf(
 			"failed to connect to %v@%v/%v: %w",
@@ -60,7 +66,39 @@ func NewDBSession(conf config.DB) (*Database, error) {
 
 func initStores(sess db.Session) *Database {
 	return &Database{
-		Session: sess,
-		User:    *Users(sess),
+		Session:        sess,
+		ApiKey:         *ApiKeys(sess),
+		IdempotencyKey: *IdempotencyKeys(sess),
+		Maintenance:    *Maintenances(sess),
+		Role:           *Roles(sess),
+		User:           *Users(sess),
 	}
 }
+
+// WithContext returns database with all stores bound to given context. Use
+// it in request handlers, so queries are canceled with the request and tagged
+// with its id.
+func (d *Database) WithContext(ctx context.Context) *Database {
+	return initStores(d.Session.WithContext(ctx))
+}
+
+// NewTx wraps given SQL transaction into Database, so all stores run their
+// queries within the transaction. Caller is responsible for Commit/Rollback.
+func NewTx(sqlTx *sql.Tx) (*Database, error) {
+	tx, err := postgresql.NewTx(sqlTx)
+	if err != nil {
+		return nil, fmt.Errorf("wrap sql transaction: %w", err)
+	}
+
+	return initStores(tx), nil
+}
+
+// TxContext runs fn within a new transaction. Sessions already bound to
+// a transaction (see NewTx) run fn directly within it.
+func TxContext(ctx context.Context, sess db.Session, fn func(sess db.Session) error) error {
+	if tx, ok := sess.(interface{ IsTransaction() bool }); ok && tx.IsTransaction() {
+		return fn(sess)
+	}
+
+	return sess.TxContext(ctx, fn, nil)
+}
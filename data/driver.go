@@ -0,0 +1,135 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v4/stdlib"
+	"github.com/upper/db/v4"
+	"github.com/upper/db/v4/adapter/postgresql"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/golang-cz/skeleton/internal/reqctx"
+	"github.com/golang-cz/skeleton/pkg/tracing"
+)
+
+// open connects to DB like postgresql.Open, with SQL queries traced and
+// optionally tagged by request id.
+func open(connURL postgresql.ConnectionURL, comments bool) (db.Session, error) {
+	connector, err := newConnector(connURL.String(), comments)
+	if err != nil {
+		return nil, fmt.Errorf("create connector: %w", err)
+	}
+
+	sqlDB := sql.OpenDB(connector)
+	sqlDB.SetConnMaxLifetime(db.DefaultSettings.ConnMaxLifetime())
+	sqlDB.SetConnMaxIdleTime(db.DefaultSettings.ConnMaxIdleTime())
+	sqlDB.SetMaxIdleConns(db.DefaultSettings.MaxIdleConns())
+	sqlDB.SetMaxOpenConns(db.DefaultSettings.MaxOpenConns())
+
+	sess, err := postgresql.New(sqlDB)
+	if err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+
+	return sess, nil
+}
+
+// connector opens pgx connections starting span for every SQL query, child
+// of the span in the query context. With comments, queries are tagged with
+// request id from the context too, eg.
+//
+//	SELECT * FROM users WHERE id = $1 /* requestId=0190a1b2-... */
+//
+// so queries showing up in pg_stat_activity or Postgres logs can be matched
+// with the request. The context is set by Database.WithContext.
+type connector struct {
+	driver.Connector
+	comments bool
+}
+
+func newConnector(dsn string, comments bool) (driver.Connector, error) {
+	c, err := stdlib.GetDefaultDriver().(driver.DriverContext).OpenConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &connector{Connector: c, comments: comments}, nil
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &instrumentedConn{Conn: conn.(*stdlib.Conn), comments: c.comments}, nil
+}
+
+// instrumentedConn overrides query methods of pgx connection, other methods
+// (transactions, ping, session reset etc.) are used as they are.
+type instrumentedConn struct {
+	*stdlib.Conn
+	comments bool
+}
+
+func (c *instrumentedConn) PrepareContext(ctx context.Context, query string) (_ driver.Stmt, err error) {
+	ctx, end := startQuerySpan(ctx, query)
+	defer func() { end(err) }()
+
+	return c.Conn.PrepareContext(ctx, c.withComment(ctx, query))
+}
+
+func (c *instrumentedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (_ driver.Result, err error) {
+	ctx, end := startQuerySpan(ctx, query)
+	defer func() { end(err) }()
+
+	return c.Conn.ExecContext(ctx, c.withComment(ctx, query), args)
+}
+
+func (c *instrumentedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (_ driver.Rows, err error) {
+	ctx, end := startQuerySpan(ctx, query)
+	defer func() { end(err) }()
+
+	return c.Conn.QueryContext(ctx, c.withComment(ctx, query), args)
+}
+
+func (c *instrumentedConn) withComment(ctx context.Context, query string) string {
+	if !c.comments {
+		return query
+	}
+	// Request ids are validated to contain no characters ending the comment.
+	if requestId := reqctx.GetRequestId(ctx); reqctx.ValidRequestId(requestId) {
+		return query + " /* requestId=" + requestId + " */"
+	}
+	return query
+}
+
+// startQuerySpan starts span named by SQL operation, eg. "SELECT". Query
+// args aren't recorded, they may hold personal data.
+func startQuerySpan(ctx context.Context, query string) (context.Context, func(err error)) {
+	operation := "QUERY"
+	if fields := strings.Fields(query); len(fields) > 0 {
+		operation = strings.ToUpper(fields[0])
+	}
+
+	ctx, span := tracing.Tracer().Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBStatement(query),
+		),
+	)
+
+	return ctx, func(err error) {
+		if err != nil && err != driver.ErrSkip {
+			tracing.RecordError(ctx, err)
+		}
+		span.End()
+	}
+}
@@ -0,0 +1,22 @@
+package data
+
+import "fmt"
+
+// ValidationError reports records or inputs which aren't valid, as opposed to
+// failures of the database. The API reports it as InvalidArgument.
+type ValidationError struct {
+	err error
+}
+
+// Invalidf formats ValidationError, %w wraps errors as in fmt.Errorf.
+func Invalidf(format string, a ...any) error {
+	return &ValidationError{err: fmt.Errorf(format, a...)}
+}
+
+func (e *ValidationError) Error() string {
+	return e.err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.err
+}
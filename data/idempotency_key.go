@@ -0,0 +1,176 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/upper/db/v4"
+
+	"github.com/golang-cz/skeleton/pkg/idempotency"
+	"github.com/golang-cz/skeleton/pkg/utc"
+)
+
+// IdempotencyKey stores response of the first request with Idempotency-Key
+// header. Status is nil while the request is in progress.
+type IdempotencyKey struct {
+	Key         string     `db:"key"`
+	Token       *string    `db:"token"` // Token of the reservation, nil once completed.
+	RequestHash []byte     `db:"request_hash"`
+	Status      *int       `db:"status"`
+	ContentType *string    `db:"content_type"`
+	Body        []byte     `db:"body"`
+	LockedUntil *time.Time `db:"locked_until"`
+	ExpiresAt   time.Time  `db:"expires_at"`
+	CreatedAt   time.Time  `db:"created_at"`
+}
+
+type IdempotencyKeyStore struct {
+	db.Collection
+}
+
+// Interface checks
+var _ = interface {
+	db.Record
+}(&IdempotencyKey{})
+
+var _ = interface {
+	db.Store
+	idempotency.Store
+}(&IdempotencyKeyStore{})
+
+func IdempotencyKeys(sess db.Session) *IdempotencyKeyStore {
+	return &IdempotencyKeyStore{sess.Collection("idempotency_keys")}
+}
+
+func (k *IdempotencyKey) Store(sess db.Session) db.Store {
+	return IdempotencyKeys(sess)
+}
+
+func (k *IdempotencyKey) record() *idempotency.Record {
+	record := &idempotency.Record{RequestHash: k.RequestHash}
+	if k.Status != nil {
+		record.Response = &idempotency.Response{Status: *k.Status, Body: k.Body}
+		if k.ContentType != nil {
+			record.Response.ContentType = *k.ContentType
+		}
+	}
+	return record
+}
+
+// Reserve inserts the key, or takes over an expired one. In-progress keys
+// expire after lockTimeout, so a crashed request doesn't block the key.
+func (s IdempotencyKeyStore) Reserve(ctx context.Context, key, token string, requestHash []byte, lockTimeout time.Duration) (*idempotency.Record, bool, error) {
+	sess := s.Session().WithContext(ctx)
+
+	// The existing key may expire or be released between the queries, try
+	// again then.
+	for i := 0; i < 3; i++ {
+		now := utc.Now()
+		lockedUntil := now.Add(lockTimeout)
+
+		row, err := sess.SQL().QueryRow(`
+			INSERT INTO idempotency_keys (key, token, request_hash, locked_until, expires_at, created_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT (key) DO UPDATE
+			SET token = EXCLUDED.token,
+				request_hash = EXCLUDED.request_hash,
+				status = NULL,
+				content_type = NULL,
+				body = NULL,
+				locked_until = EXCLUDED.locked_until,
+				expires_at = EXCLUDED.expires_at,
+				created_at = EXCLUDED.created_at
+			WHERE idempotency_keys.expires_at < EXCLUDED.created_at
+			RETURNING key`,
+			key, token, requestHash, lockedUntil, lockedUntil, now,
+		)
+		if err != nil {
+			return nil, false, fmt.Errorf("reserve key: %w", err)
+		}
+
+		var inserted string
+		err = row.Scan(&inserted)
+		if err == nil {
+			return nil, true, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, false, fmt.Errorf("reserve key: %w", err)
+		}
+
+		var existing IdempotencyKey
+		err = IdempotencyKeys(sess).Find(db.Cond{"key": key}).One(&existing)
+		if errors.Is(err, db.ErrNoMoreRows) {
+			continue
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("get first record: %w", err)
+		}
+
+		return existing.record(), false, nil
+	}
+
+	return nil, false, errors.New("reserve key: record keeps expiring")
+}
+
+func (s IdempotencyKeyStore) Complete(ctx context.Context, key, token string, requestHash []byte, resp *idempotency.Response, ttl time.Duration) error {
+	res, err := s.Session().WithContext(ctx).SQL().
+		Update("idempotency_keys").
+		Set(
+			"token", nil,
+			"request_hash", requestHash,
+			"status", resp.Status,
+			"content_type", resp.ContentType,
+			"body", resp.Body,
+			"locked_until", nil,
+			"expires_at", utc.Now().Add(ttl),
+		).
+		Where(db.Cond{"key": key, "token": token}).
+		Exec()
+	if err != nil {
+		return fmt.Errorf("store response: %w", err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if count == 0 {
+		return idempotency.ErrReservationLost
+	}
+
+	return nil
+}
+
+func (s IdempotencyKeyStore) Release(ctx context.Context, key, token string) error {
+	sess := s.Session().WithContext(ctx)
+
+	if err := IdempotencyKeys(sess).Find(db.Cond{"key": key, "token": token}).Delete(); err != nil {
+		return fmt.Errorf("release key: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired deletes up to batchSize expired keys and returns their count.
+func (s IdempotencyKeyStore) DeleteExpired(ctx context.Context, batchSize int) (int, error) {
+	res, err := s.Session().WithContext(ctx).SQL().Exec(`
+		DELETE FROM idempotency_keys
+		WHERE key IN (
+			SELECT key FROM idempotency_keys WHERE expires_at < ? LIMIT ?
+		)`,
+		utc.Now(), batchSize,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("delete expired keys: %w", err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("rows affected: %w", err)
+	}
+
+	return int(count), nil
+}
@@ -0,0 +1,66 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/upper/db/v4"
+
+	"github.com/golang-cz/skeleton/pkg/maintenance"
+)
+
+// MaintenanceStore keeps maintenance mode state in a single row.
+type MaintenanceStore struct {
+	db.Collection
+}
+
+// Interface checks
+var _ = interface {
+	db.Store
+	maintenance.Store
+}(&MaintenanceStore{})
+
+func Maintenances(sess db.Session) *MaintenanceStore {
+	return &MaintenanceStore{sess.Collection("maintenance")}
+}
+
+func (s MaintenanceStore) Get(ctx context.Context) (*maintenance.State, error) {
+	sess := s.Session().WithContext(ctx)
+
+	var state maintenance.State
+	row, err := sess.SQL().QueryRow(`SELECT enabled, reason, eta, updated_at FROM maintenance`)
+	if err != nil {
+		return nil, fmt.Errorf("get state: %w", err)
+	}
+	err = row.Scan(&state.Enabled, &state.Reason, &state.ETA, &state.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get state: %w", err)
+	}
+
+	return &state, nil
+}
+
+func (s MaintenanceStore) Save(ctx context.Context, state *maintenance.State) error {
+	sess := s.Session().WithContext(ctx)
+
+	_, err := sess.SQL().Exec(`
+		INSERT INTO maintenance (enabled, reason, eta, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE
+		SET enabled = EXCLUDED.enabled,
+			reason = EXCLUDED.reason,
+			eta = EXCLUDED.eta,
+			updated_at = EXCLUDED.updated_at`,
+		state.Enabled, state.Reason, state.ETA, state.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("save state: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/pressly/goose/v3"
+
+	"github.com/golang-cz/skeleton/config"
+	"github.com/golang-cz/skeleton/data"
+)
+
+// Rename of a migration file done by Fix.
+type Rename struct {
+	OldVersion int64
+	NewVersion int64
+	OldName    string
+	NewName    string
+}
+
+func (r Rename) String() string {
+	return fmt.Sprintf("%s => %s", r.OldName, r.NewName)
+}
+
+// CollisionError reports sequential versions used by more than one
+// migration, typically after merging two branches. They need to be resolved
+// by hand, eg. by renaming one of them back to a timestamp.
+type CollisionError struct {
+	Collisions map[int64][]string
+}
+
+func (e *CollisionError) Error() string {
+	versions := make([]int64, 0, len(e.Collisions))
+	for version := range e.Collisions {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+	var b strings.Builder
+	b.WriteString("migration version collision:")
+	for _, version := range versions {
+		fmt.Fprintf(&b, "\n\t%d: %s", version, strings.Join(e.Collisions[version], ", "))
+	}
+	return b.String()
+}
+
+// Fix returns renames of timestamped migrations in dir to sequential versions
+// following the highest sequential one, in order of their timestamps.
+// Timestamped migrations sharing a version are ordered by name. Files are
+// renamed by RenameFiles, after FixDBVersions updates the DB.
+func Fix(dir string) ([]Rename, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("list migrations: %w", err)
+	}
+
+	var (
+		timestamped []Rename
+		files       = map[int64][]string{}
+		next        = int64(1)
+	)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		name := entry.Name()
+		version, err := goose.NumericComponent(name)
+		if err != nil {
+			continue // Not a migration, eg. migrations.go.
+		}
+		files[version] = append(files[version], name)
+
+		if isTimestamped(version) {
+			timestamped = append(timestamped, Rename{OldVersion: version, OldName: name})
+		} else {
+			next = max(next, version+1)
+		}
+	}
+
+	collisions := map[int64][]string{}
+	for version, names := range files {
+		if len(names) > 1 && !isTimestamped(version) {
+			collisions[version] = names
+		}
+	}
+	if len(collisions) > 0 {
+		return nil, &CollisionError{Collisions: collisions}
+	}
+
+	sort.Slice(timestamped, func(i, j int) bool {
+		if timestamped[i].OldVersion != timestamped[j].OldVersion {
+			return timestamped[i].OldVersion < timestamped[j].OldVersion
+		}
+		return timestamped[i].OldName < timestamped[j].OldName
+	})
+
+	for i := range timestamped {
+		r := &timestamped[i]
+		r.NewVersion = next
+		r.NewName = fmt.Sprintf("%05d", next) + strings.TrimPrefix(r.OldName, strconv.FormatInt(r.OldVersion, 10))
+		next++
+	}
+
+	return timestamped, nil
+}
+
+// RenameFiles renames migration files in dir as returned by Fix.
+func RenameFiles(dir string, renames []Rename) error {
+	for _, r := range renames {
+		if err := os.Rename(filepath.Join(dir, r.OldName), filepath.Join(dir, r.NewName)); err != nil {
+			return fmt.Errorf("rename migration %s: %w", r, err)
+		}
+	}
+
+	return nil
+}
+
+// FixDBVersions applies renames returned by Fix to goose_db_version table in
+// one transaction, so databases with already applied timestamped migrations
+// keep working. Run it before RenameFiles, so a failure leaves both the DB and
+// the files untouched.
+func FixDBVersions(ctx context.Context, conf config.DB, renames []Rename) error {
+	if len(renames) == 0 {
+		return nil
+	}
+
+	sess, err := data.NewDBSession(conf)
+	if err != nil {
+		return fmt.Errorf("connect to DB: %w", err)
+	}
+	defer sess.Close()
+
+	db := sess.Driver().(*sql.DB)
+
+	var exists bool
+	if err := db.QueryRowContext(ctx, `SELECT to_regclass($1) IS NOT NULL`, goose.TableName()).Scan(&exists); err != nil {
+		return fmt.Errorf("check version table: %w", err)
+	}
+	if !exists {
+		return nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	table := pq.QuoteIdentifier(goose.TableName())
+	for _, r := range renames {
+		var taken bool
+		if err := tx.QueryRowContext(ctx, fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE version_id = $1)`, table), r.NewVersion).Scan(&taken); err != nil {
+			return fmt.Errorf("check version %d: %w", r.NewVersion, err)
+		}
+		if taken {
+			return fmt.Errorf("version %d is already recorded in DB, fix %s by hand", r.NewVersion, goose.TableName())
+		}
+
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`UPDATE %s SET version_id = $1 WHERE version_id = $2`, table), r.NewVersion, r.OldVersion); err != nil {
+			return fmt.Errorf("update version %d: %w", r.OldVersion, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package migration
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestFix(t *testing.T) {
+	t.Parallel()
+
+	dir := writeMigrations(t,
+		"00001_create_users.sql",
+		"00002_add_users_email.go",
+		"20261019130000_create_idempotency_keys.sql",
+		"20261019120000_b_create_roles.sql",
+		"20261019120000_a_create_permissions.sql",
+		"migrations.go",
+	)
+
+	renames, err := Fix(dir)
+	if err != nil {
+		t.Fatalf("fix: %v", err)
+	}
+
+	want := []Rename{
+		{OldVersion: 20261019120000, NewVersion: 3, OldName: "20261019120000_a_create_permissions.sql", NewName: "00003_a_create_permissions.sql"},
+		{OldVersion: 20261019120000, NewVersion: 4, OldName: "20261019120000_b_create_roles.sql", NewName: "00004_b_create_roles.sql"},
+		{OldVersion: 20261019130000, NewVersion: 5, OldName: "20261019130000_create_idempotency_keys.sql", NewName: "00005_create_idempotency_keys.sql"},
+	}
+	if !reflect.DeepEqual(renames, want) {
+		t.Fatalf("got renames %v, want %v", renames, want)
+	}
+
+	// Nothing is renamed until RenameFiles.
+	if got := listMigrations(t, dir); got[2] != "20261019120000_a_create_permissions.sql" {
+		t.Fatalf("files renamed by Fix: %v", got)
+	}
+
+	if err := RenameFiles(dir, renames); err != nil {
+		t.Fatalf("rename files: %v", err)
+	}
+
+	wantFiles := []string{
+		"00001_create_users.sql",
+		"00002_add_users_email.go",
+		"00003_a_create_permissions.sql",
+		"00004_b_create_roles.sql",
+		"00005_create_idempotency_keys.sql",
+		"migrations.go",
+	}
+	if got := listMigrations(t, dir); !reflect.DeepEqual(got, wantFiles) {
+		t.Errorf("got files %v, want %v", got, wantFiles)
+	}
+
+	renames, err = Fix(dir)
+	if err != nil {
+		t.Fatalf("fix again: %v", err)
+	}
+	if len(renames) != 0 {
+		t.Errorf("got renames %v after fix, want none", renames)
+	}
+}
+
+func TestFixCollision(t *testing.T) {
+	t.Parallel()
+
+	dir := writeMigrations(t,
+		"00001_create_users.sql",
+		"00002_create_roles.sql",
+		"00002_create_permissions.sql",
+		"20261019130000_create_idempotency_keys.sql",
+	)
+
+	renames, err := Fix(dir)
+
+	var collisionErr *CollisionError
+	if !errors.As(err, &collisionErr) {
+		t.Fatalf("got error %v, want CollisionError", err)
+	}
+	if renames != nil {
+		t.Errorf("got renames %v, want none", renames)
+	}
+
+	want := map[int64][]string{2: {"00002_create_permissions.sql", "00002_create_roles.sql"}}
+	if !reflect.DeepEqual(collisionErr.Collisions, want) {
+		t.Errorf("got collisions %v, want %v", collisionErr.Collisions, want)
+	}
+}
+
+func writeMigrations(t *testing.T, names ...string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	return dir
+}
+
+func listMigrations(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("list migrations: %v", err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,340 @@
+package migration
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Lint rules. A finding can be suppressed by an annotation in the comment
+// right above the offending statement, eg.
+//
+//	-- lint:ignore drop-statement legacy table is not used since v23.10
+//	DROP TABLE legacy;
+const (
+	RuleCreateIndexConcurrently = "create-index-concurrently"
+	RuleAddColumnNotNull        = "add-column-not-null"
+	RuleAlterColumnType         = "alter-column-type"
+	RuleMissingDown             = "missing-down"
+	RuleDropStatement           = "drop-statement"
+)
+
+type LintFinding struct {
+	File string
+	Line int
+	Rule string
+	Msg  string
+}
+
+func (f LintFinding) String() string {
+	return fmt.Sprintf("%s:%d: [%s] %s", f.File, f.Line, f.Rule, f.Msg)
+}
+
+var (
+	reCreateTable      = regexp.MustCompile(`(?i)^CREATE\s+(?:UNLOGGED\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?([\w."]+)`)
+	reCreateIndex      = regexp.MustCompile(`(?i)^CREATE\s+(?:UNIQUE\s+)?INDEX\s+(CONCURRENTLY\s+)?(?:IF\s+NOT\s+EXISTS\s+)?(?:[\w"]+\s+)?ON\s+(?:ONLY\s+)?([\w."]+)`)
+	reAlterTable       = regexp.MustCompile(`(?i)^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?([\w."]+)`)
+	reAddColumn        = regexp.MustCompile(`(?i)\bADD\s+(?:COLUMN\s+)?(?:IF\s+NOT\s+EXISTS\s+)?[\w"]+\s+[^,]*`)
+	reAlterColumnType  = regexp.MustCompile(`(?i)\bALTER\s+(?:COLUMN\s+)?[\w"]+\s+(?:SET\s+DATA\s+)?TYPE\b`)
+	reDrop             = regexp.MustCompile(`(?i)^(?:DROP\s+\w+|ALTER\s+TABLE\b.*\bDROP\s+(?:COLUMN|CONSTRAINT)\b)`)
+	reNotNull          = regexp.MustCompile(`(?i)\bNOT\s+NULL\b`)
+	reDefault          = regexp.MustCompile(`(?i)\bDEFAULT\b`)
+	reAddConstraint    = regexp.MustCompile(`(?i)^ADD\s+(?:CONSTRAINT|PRIMARY|UNIQUE|FOREIGN|CHECK|EXCLUDE)\b`)
+	reIgnoreAnnotation = regexp.MustCompile(`lint:ignore\s+([\w,-]+)`)
+)
+
+// Lint checks SQL migrations in given directory for statements that are
+// dangerous to run against a live production database. When no files are
+// given, all *.sql files in the directory are checked.
+func Lint(dir string, files ...string) ([]LintFinding, error) {
+	if len(files) == 0 {
+		var err error
+		files, err = fs.Glob(os.DirFS(dir), "*.sql")
+		if err != nil {
+			return nil, fmt.Errorf("list migrations: %w", err)
+		}
+	} else {
+		for i, f := range files {
+			files[i] = filepath.Base(f)
+		}
+	}
+	sort.Strings(files)
+
+	var findings []LintFinding
+	for _, file := range files {
+		content, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			return nil, fmt.Errorf("read migration: %w", err)
+		}
+
+		findings = append(findings, lintMigration(file, string(content))...)
+	}
+
+	return findings, nil
+}
+
+func lintMigration(file, content string) []LintFinding {
+	var findings []LintFinding
+	reported := map[int]map[string]bool{} // Statement line -> rule.
+	report := func(stmt statement, rule, msg string) {
+		if stmt.ignores(rule) || reported[stmt.line][rule] {
+			return
+		}
+		findings = append(findings, LintFinding{File: file, Line: stmt.line, Rule: rule, Msg: msg})
+
+		if reported[stmt.line] == nil {
+			reported[stmt.line] = map[string]bool{}
+		}
+		reported[stmt.line][rule] = true
+	}
+
+	up, hasDown := upSection(content)
+	if !hasDown {
+		report(statement{line: 1}, RuleMissingDown, "migration has no -- +goose Down section")
+	}
+
+	// Tables created within the migration are empty, so they can't be locked
+	// for long or rewritten.
+	created := map[string]bool{}
+
+	for _, stmt := range splitStatements(up) {
+		sql := stmt.sql
+
+		if m := reCreateTable.FindStringSubmatch(sql); m != nil {
+			created[tableName(m[1])] = true
+			continue
+		}
+
+		if m := reCreateIndex.FindStringSubmatch(sql); m != nil {
+			if m[1] == "" && !created[tableName(m[2])] {
+				report(stmt, RuleCreateIndexConcurrently, "CREATE INDEX without CONCURRENTLY blocks writes to the table, use CREATE INDEX CONCURRENTLY with -- +goose NO TRANSACTION")
+			}
+			continue
+		}
+
+		if reDrop.MatchString(sql) {
+			report(stmt, RuleDropStatement, "DROP statement needs an explicit -- lint:ignore drop-statement <reason> annotation")
+		}
+
+		m := reAlterTable.FindStringSubmatch(sql)
+		if m == nil || created[tableName(m[1])] {
+			continue
+		}
+
+		for _, action := range splitTopLevel(sql[len(m[0]):], ',') {
+			action = strings.TrimSpace(action)
+
+			if reAddColumn.MatchString(action) && !reAddConstraint.MatchString(action) &&
+				reNotNull.MatchString(action) && !reDefault.MatchString(action) {
+				report(stmt, RuleAddColumnNotNull, "adding NOT NULL column without DEFAULT fails on non-empty tables")
+			}
+
+			if reAlterColumnType.MatchString(action) {
+				report(stmt, RuleAlterColumnType, "changing column type may rewrite the whole table under an exclusive lock")
+			}
+		}
+	}
+
+	return findings
+}
+
+// upSection returns the part of migration between -- +goose Up and
+// -- +goose Down annotations and reports whether the Down section exists.
+func upSection(content string) (up string, hasDown bool) {
+	lines := strings.SplitAfter(content, "\n")
+
+	var b strings.Builder
+	inUp := false
+	for _, line := range lines {
+		switch annotation(line) {
+		case "+goose Up":
+			inUp = true
+			b.WriteString("\n") // Keep line numbers.
+			continue
+		case "+goose Down":
+			return b.String(), true
+		}
+
+		if inUp {
+			b.WriteString(line)
+		} else {
+			b.WriteString("\n")
+		}
+	}
+
+	return b.String(), false
+}
+
+func annotation(line string) string {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "--") {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(line, "--"))
+}
+
+func tableName(name string) string {
+	name = strings.ToLower(strings.ReplaceAll(name, `"`, ""))
+	return strings.TrimPrefix(name, "public.")
+}
+
+type statement struct {
+	sql      string // Normalized whitespace, for matching.
+	raw      string // Original text, for execution.
+	line     int
+	comments []string
+}
+
+func (s statement) ignores(rule string) bool {
+	for _, c := range s.comments {
+		m := reIgnoreAnnotation.FindStringSubmatch(c)
+		if m == nil {
+			continue
+		}
+		for _, r := range strings.Split(m[1], ",") {
+			if r == rule {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// splitStatements splits SQL into statements, skipping comments, string
+// literals and dollar-quoted bodies. Comments preceding a statement are kept
+// for lint annotations.
+func splitStatements(sql string) []statement {
+	var (
+		stmts    []statement
+		b        strings.Builder
+		comments []string
+		line     = 1
+		start    = 0
+		startOff = 0
+	)
+
+	flush := func(end int) {
+		text := strings.Join(strings.Fields(b.String()), " ")
+		if text != "" {
+			stmts = append(stmts, statement{sql: text, raw: strings.TrimSpace(sql[startOff:end]), line: start, comments: comments})
+		}
+		b.Reset()
+		comments = nil
+		start = 0
+	}
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+
+		switch {
+		case c == '\n':
+			line++
+			b.WriteByte(' ')
+			continue
+
+		case strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+			comments = append(comments, sql[i:i+end])
+			i += end - 1
+			continue
+
+		case strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i:], "*/")
+			if end < 0 {
+				end = len(sql) - i - 2
+			}
+			comment := sql[i : i+end+2]
+			comments = append(comments, comment)
+			line += strings.Count(comment, "\n")
+			i += end + 1
+			continue
+
+		case c == ';':
+			flush(i)
+			continue
+		}
+
+		if start == 0 && c != ' ' && c != '\t' && c != '\r' {
+			start = line
+			startOff = i
+		}
+
+		// Copy quoted parts verbatim so their content is never interpreted.
+		if quoted := quotedAt(sql[i:]); quoted != "" {
+			b.WriteString(quoted)
+			line += strings.Count(quoted, "\n")
+			i += len(quoted) - 1
+			continue
+		}
+
+		b.WriteByte(c)
+	}
+	flush(len(sql))
+
+	return stmts
+}
+
+var reDollarTag = regexp.MustCompile(`^\$(?:[A-Za-z_]\w*)?\$`)
+
+// quotedAt returns the string literal, quoted identifier or dollar-quoted
+// body starting at the beginning of s, if any.
+func quotedAt(s string) string {
+	switch s[0] {
+	case '\'', '"':
+		for i := 1; i < len(s); i++ {
+			if s[i] == s[0] {
+				if i+1 < len(s) && s[i+1] == s[0] { // Escaped quote.
+					i++
+					continue
+				}
+				return s[:i+1]
+			}
+		}
+		return s
+	case '$':
+		tag := reDollarTag.FindString(s)
+		if tag == "" {
+			return ""
+		}
+		end := strings.Index(s[len(tag):], tag)
+		if end < 0 {
+			return s
+		}
+		return s[:len(tag)+end+len(tag)]
+	}
+	return ""
+}
+
+// splitTopLevel splits s by sep outside of parentheses and quotes.
+func splitTopLevel(s string, sep byte) []string {
+	var (
+		parts []string
+		depth int
+		last  int
+	)
+	for i := 0; i < len(s); i++ {
+		if quoted := quotedAt(s[i:]); quoted != "" {
+			i += len(quoted) - 1
+			continue
+		}
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case sep:
+			if depth == 0 {
+				parts = append(parts, s[last:i])
+				last = i + 1
+			}
+		}
+	}
+	return append(parts, s[last:])
+}
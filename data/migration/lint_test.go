@@ -0,0 +1,192 @@
+package migration
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestLintMigration(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		name string
+		up   string
+		want []string // rule@line, line 1 is the first line of up
+	}{
+		{
+			name: "create index",
+			up:   "CREATE INDEX users_email_idx ON users (email);",
+			want: []string{"create-index-concurrently@1"},
+		},
+		{
+			name: "create index concurrently",
+			up:   "CREATE INDEX CONCURRENTLY users_email_idx ON users (email);",
+		},
+		{
+			name: "create index on table created in the migration",
+			up: `CREATE TABLE accounts (id UUID PRIMARY KEY, email TEXT);
+CREATE UNIQUE INDEX accounts_email_idx ON public."accounts" (email);`,
+		},
+		{
+			name: "drop table",
+			up:   "DROP TABLE legacy;",
+			want: []string{"drop-statement@1"},
+		},
+		{
+			name: "drop column",
+			up:   "ALTER TABLE users DROP COLUMN nickname;",
+			want: []string{"drop-statement@1"},
+		},
+		{
+			name: "drop default",
+			up:   "ALTER TABLE users ALTER COLUMN nickname DROP DEFAULT;",
+		},
+		{
+			name: "add not null column",
+			up: `ALTER TABLE users
+    ADD COLUMN nickname TEXT NOT NULL;`,
+			want: []string{"add-column-not-null@1"},
+		},
+		{
+			name: "add not null column with default",
+			up:   "ALTER TABLE users ADD COLUMN nickname TEXT NOT NULL DEFAULT '';",
+		},
+		{
+			name: "add nullable column",
+			up:   "ALTER TABLE users ADD COLUMN nickname TEXT;",
+		},
+		{
+			name: "add not null column to table created in the migration",
+			up: `CREATE TABLE accounts (id UUID PRIMARY KEY);
+ALTER TABLE accounts ADD COLUMN email TEXT NOT NULL;`,
+		},
+		{
+			name: "add constraint",
+			up:   "ALTER TABLE users ADD CONSTRAINT users_email_check CHECK (email IS NOT NULL);",
+		},
+		{
+			name: "alter column type",
+			up:   "ALTER TABLE users ALTER COLUMN email TYPE VARCHAR(320);",
+			want: []string{"alter-column-type@1"},
+		},
+		{
+			name: "alter column set data type",
+			up:   "ALTER TABLE users ALTER email SET DATA TYPE VARCHAR(320), ADD COLUMN nickname TEXT NOT NULL;",
+			want: []string{"alter-column-type@1", "add-column-not-null@1"},
+		},
+		{
+			name: "alter column set not null",
+			up:   "ALTER TABLE users ALTER COLUMN email SET NOT NULL;",
+		},
+		{
+			name: "lint:ignore",
+			up: `-- lint:ignore drop-statement legacy table is not used since v23.10
+DROP TABLE legacy;`,
+		},
+		{
+			name: "lint:ignore multiple rules",
+			up: `/* lint:ignore add-column-not-null,alter-column-type table is empty */
+ALTER TABLE users ALTER COLUMN email TYPE TEXT, ADD COLUMN nickname TEXT NOT NULL;`,
+		},
+		{
+			name: "lint:ignore other rule",
+			up: `-- lint:ignore drop-statement wrong rule
+CREATE INDEX users_email_idx ON users (email);`,
+			want: []string{"create-index-concurrently@2"},
+		},
+		{
+			name: "lint:ignore applies to the next statement only",
+			up: `-- lint:ignore drop-statement legacy table is not used since v23.10
+DROP TABLE legacy;
+DROP TABLE legacy_archive;`,
+			want: []string{"drop-statement@3"},
+		},
+		{
+			name: "dollar-quoted body",
+			up: `CREATE FUNCTION cleanup() RETURNS void AS $$
+BEGIN
+    DROP TABLE tmp;
+    CREATE INDEX tmp_idx ON tmp (id);
+END;
+$$ LANGUAGE plpgsql;
+DROP TABLE legacy;`,
+			want: []string{"drop-statement@7"},
+		},
+		{
+			name: "tagged dollar-quoted body",
+			up: `DO $body$
+BEGIN
+    EXECUTE 'DROP TABLE tmp; ALTER TABLE users ADD COLUMN x TEXT NOT NULL';
+    PERFORM $$ nested $$;
+END;
+$body$;
+CREATE INDEX users_email_idx ON users (email);`,
+			want: []string{"create-index-concurrently@7"},
+		},
+		{
+			name: "string literal",
+			up: `INSERT INTO notes (text) VALUES ('DROP TABLE users; it''s fine');
+DROP TABLE legacy;`,
+			want: []string{"drop-statement@2"},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			content := "-- +goose Up\n" + tc.up + "\n\n-- +goose Down\nSELECT 1;\n"
+
+			var got []string
+			for _, f := range lintMigration("test.sql", content) {
+				got = append(got, fmt.Sprintf("%s@%d", f.Rule, f.Line-1))
+			}
+
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got findings %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLintMigrationMissingDown(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "missing down",
+			content: "-- +goose Up\nCREATE TABLE accounts (id UUID);\n",
+			want:    []string{"missing-down@1"},
+		},
+		{
+			name:    "empty down",
+			content: "-- +goose Up\nCREATE TABLE accounts (id UUID);\n\n-- +goose Down\n",
+		},
+		{
+			name:    "statements in down are not checked",
+			content: "-- +goose Up\nCREATE TABLE accounts (id UUID);\n\n-- +goose Down\nDROP TABLE accounts;\n",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []string
+			for _, f := range lintMigration("test.sql", tc.content) {
+				got = append(got, fmt.Sprintf("%s@%d", f.Rule, f.Line))
+			}
+
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got findings %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
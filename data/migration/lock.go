@@ -0,0 +1,58 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// lockId is a Postgres advisory lock key serializing migration runs, eg. of
+// two deploy jobs. Advisory locks are scoped to the current database.
+const lockId = 7081_0000
+
+// defaultLockTimeout is used when goose.lock_timeout isn't configured.
+const defaultLockTimeout = time.Minute
+
+// lock takes the migration advisory lock, waiting at most timeout for
+// other runs to finish. The lock is held by a dedicated connection until
+// the returned unlock func is called.
+func lock(ctx context.Context, db *sql.DB, timeout time.Duration) (unlock func(), err error) {
+	if timeout <= 0 {
+		timeout = defaultLockTimeout
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get connection: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		var locked bool
+		if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, lockId).Scan(&locked); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("take advisory lock: %w", err)
+		}
+
+		if locked {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			conn.Close()
+			return nil, fmt.Errorf("migrations are locked by another run for more than %v", timeout)
+		}
+
+		slog.Info("waiting for another migration run to finish")
+		time.Sleep(time.Second)
+	}
+
+	return func() {
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockId); err != nil {
+			slog.Error("failed to release migration lock", "err", err)
+		}
+		conn.Close()
+	}, nil
+}
@@ -1,21 +1,55 @@
 package migration
 
 import (
+	"context"
 	"database/sql"
 	"embed"
 	"errors"
 	"fmt"
+	"text/template"
+	"time"
 
 	"github.com/lib/pq"
 	"github.com/pressly/goose/v3"
 
 	"github.com/golang-cz/skeleton/config"
 	"github.com/golang-cz/skeleton/data"
+
+	// Register Go migrations.
+	_ "github.com/golang-cz/skeleton/data/migration/migrations"
 )
 
 //go:embed migrations/*.sql
 var migrations embed.FS
 
+func init() {
+	// Set once, status probes collect migrations concurrently.
+	goose.SetBaseFS(migrations)
+}
+
+var goMigrationTemplate = template.Must(template.New("go-migration").Parse(`package migrations
+
+import (
+	"context"
+
+	"github.com/golang-cz/skeleton/data"
+)
+
+func init() {
+	addMigration(up{{.CamelName}}, down{{.CamelName}})
+}
+
+func up{{.CamelName}}(ctx context.Context, db *data.Database) error {
+	// This code is executed when the migration is applied.
+	return nil
+}
+
+func down{{.CamelName}}(ctx context.Context, db *data.Database) error {
+	// This code is executed when the migration is rolled back.
+	return nil
+}
+`))
+
 func RunMigrations(args []string, conf *config.Config) error {
 	db, err := data.NewDBSession(conf.DB)
 	if err != nil {
@@ -35,18 +69,30 @@ func RunMigrations(args []string, conf *config.Config) error {
 		return fmt.Errorf("collect migrations: %w", err)
 	}
 
-	// We don't allow "timestamped" migration version numbers to be run outside of "local" / "test" environment.
-	// Timestamp migration get fixed by CI pipeline and gets renamed to sequential order
-	if conf.Environment.IsProduction() {
-		for _, m := range collectedMigrations {
-			// If the version is bigger than 20000000000000, we assume it's a "timestamp" version, which shouldn't be merged in.
-			if m.Version >= 20000000000000 {
-				return fmt.Errorf("cannot run timestamped migration %q on non-dev environment", m)
-			}
-		}
+	if err := checkVersions(conf, collectedMigrations); err != nil {
+		return err
 	}
 
 	cmd := args[0]
+
+	// Commands changing the schema must not run concurrently. The lock has
+	// its own session, as the migration one is reopened below.
+	switch cmd {
+	case "create", "status", "version":
+	default:
+		lockSess, err := data.NewDBSession(conf.DB)
+		if err != nil {
+			return fmt.Errorf("connect to DB: %w", err)
+		}
+		defer lockSess.Close()
+
+		unlock, err := lock(context.Background(), lockSess.Driver().(*sql.DB), time.Duration(conf.Goose.LockTimeout))
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
 	var loop bool
 	if cmd == "up" {
 		cmd = "up-by-one"
@@ -56,13 +102,21 @@ func RunMigrations(args []string, conf *config.Config) error {
 	dir := "migrations"
 	if cmd == "create" {
 		dir = conf.Goose.Dir
-		if len(args[1:]) == 1 {
-			return fmt.Errorf("missing name for migration")
+		if len(args[1:]) < 2 {
+			return fmt.Errorf("missing name or type (sql, go) of migration")
 		}
 
 		if len(args[1:]) > 2 {
 			return fmt.Errorf("too many arguments %v", args[1:])
 		}
+
+		if args[2] == "go" {
+			err := goose.CreateWithTemplate(nil, dir, goMigrationTemplate, args[1], "go")
+			if err != nil {
+				return fmt.Errorf("create go migration: %w", err)
+			}
+			return nil
+		}
 	}
 
 	for {
@@ -111,3 +165,26 @@ func RunMigrations(args []string, conf *config.Config) error {
 
 	return nil
 }
+
+// checkVersions doesn't allow "timestamped" migration version numbers to be run outside of "local" / "test" environment.
+// Collected migrations include the registered Go migrations too.
+// Timestamp migrations get renamed to sequential order by "goose fix", see Fix.
+func checkVersions(conf *config.Config, migrations goose.Migrations) error {
+	if !conf.Environment.IsProduction() {
+		return nil
+	}
+
+	for _, m := range migrations {
+		if isTimestamped(m.Version) {
+			return fmt.Errorf("cannot run timestamped migration %q on non-dev environment", m)
+		}
+	}
+
+	return nil
+}
+
+// isTimestamped reports whether the version is a "timestamp" version, which
+// shouldn't be merged in.
+func isTimestamped(version int64) bool {
+	return version >= 20000000000000
+}
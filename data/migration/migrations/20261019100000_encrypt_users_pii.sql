@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+-- PII is encrypted by the application. Existing plaintext values are kept as
+-- bytes and get encrypted by the "reencrypt-users" scheduler job.
+--
+-- Columns change type in place, which rewrites the table and builds the
+-- indexes under an exclusive lock. Users table is small, so it takes a
+-- moment; adding new columns, backfilling and swapping them would require
+-- the application to write both variants meanwhile for no gain.
+-- lint:ignore alter-column-type users table is small, the rewrite is fast
+ALTER TABLE users
+    ALTER COLUMN email TYPE BYTEA USING convert_to(email, 'UTF8'),
+    ALTER COLUMN firstname TYPE BYTEA USING convert_to(firstname, 'UTF8'),
+    ALTER COLUMN lastname TYPE BYTEA USING convert_to(lastname, 'UTF8'),
+    ADD COLUMN email_index BYTEA,
+    ADD COLUMN encryption_key_id VARCHAR(255);
+
+-- lint:ignore create-index-concurrently users table is small
+CREATE INDEX users_email_index_idx ON users USING btree (email_index);
+-- lint:ignore create-index-concurrently users table is small
+CREATE INDEX users_encryption_key_id_idx ON users USING btree (encryption_key_id);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+-- Rows encrypted by the application can't be converted back in SQL.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM users WHERE encryption_key_id IS NOT NULL) THEN
+        RAISE EXCEPTION 'users table contains encrypted rows';
+    END IF;
+END
+$$;
+
+DROP INDEX IF EXISTS users_encryption_key_id_idx;
+DROP INDEX IF EXISTS users_email_index_idx;
+
+ALTER TABLE users
+    DROP COLUMN encryption_key_id,
+    DROP COLUMN email_index,
+    ALTER COLUMN email TYPE VARCHAR(255) USING convert_from(email, 'UTF8'),
+    ALTER COLUMN firstname TYPE VARCHAR(255) USING convert_from(firstname, 'UTF8'),
+    ALTER COLUMN lastname TYPE VARCHAR(255) USING convert_from(lastname, 'UTF8');
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang-cz/skeleton/data"
+)
+
+func init() {
+	// No down migration, encrypted users stay readable after rollback.
+	addMigration(upEncryptPlaintextUsers, nil)
+}
+
+// upEncryptPlaintextUsers encrypts users stored before PII encryption, so
+// the API doesn't depend on the scheduler catching up after deploy.
+func upEncryptPlaintextUsers(ctx context.Context, db *data.Database) error {
+	for {
+		count, err := db.User.Reencrypt(ctx, 1000)
+		if err != nil {
+			return fmt.Errorf("encrypt users: %w", err)
+		}
+
+		if count == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE api_keys
+(
+    id           UUID PRIMARY KEY NOT NULL,
+    name         VARCHAR(255) NOT NULL,
+    prefix       VARCHAR(16)  NOT NULL,
+    key_hash     BYTEA        NOT NULL,
+    scopes       TEXT[]       NOT NULL DEFAULT '{}',
+    created_by   UUID REFERENCES users (id),
+    expires_at   TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at   TIMESTAMP,
+    created_at   TIMESTAMP    NOT NULL,
+    updated_at   TIMESTAMP    NOT NULL
+);
+
+CREATE UNIQUE INDEX api_keys_prefix_idx ON api_keys USING btree (prefix);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS api_keys;
+-- +goose StatementEnd
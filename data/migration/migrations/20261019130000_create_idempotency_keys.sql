@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE idempotency_keys
+(
+    key          VARCHAR(512) PRIMARY KEY NOT NULL,
+    request_hash BYTEA        NOT NULL,
+    status       INTEGER,
+    content_type VARCHAR(255),
+    body         BYTEA,
+    -- Reservation of an in-progress request, only the request holding the
+    -- token may complete or release it.
+    locked_until TIMESTAMP,
+    token        VARCHAR(64),
+    expires_at   TIMESTAMP    NOT NULL,
+    created_at   TIMESTAMP    NOT NULL
+);
+
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys USING btree (expires_at);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS idempotency_keys;
+-- +goose StatementEnd
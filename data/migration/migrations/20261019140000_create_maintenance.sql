@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE maintenance
+(
+    id         BOOLEAN PRIMARY KEY NOT NULL DEFAULT TRUE CHECK (id), -- Single row.
+    enabled    BOOLEAN       NOT NULL,
+    reason     VARCHAR(1024) NOT NULL DEFAULT '',
+    eta        TIMESTAMP,
+    updated_at TIMESTAMP     NOT NULL
+);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS maintenance;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Deleted users keep their email, so it can be used again by a new user.
+-- lint:ignore create-index-concurrently users table is small
+CREATE UNIQUE INDEX users_active_email_index_key ON users USING btree (email_index) WHERE deleted_at IS NULL;
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS users_active_email_index_key;
+-- +goose StatementEnd
@@ -0,0 +1,71 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE permissions
+(
+    name        VARCHAR(255) PRIMARY KEY NOT NULL,
+    description VARCHAR(1024) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE roles
+(
+    id          UUID PRIMARY KEY NOT NULL,
+    name        VARCHAR(255)  NOT NULL,
+    description VARCHAR(1024) NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX roles_name_idx ON roles USING btree (name);
+
+CREATE TABLE role_permissions
+(
+    role_id    UUID         NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    permission VARCHAR(255) NOT NULL REFERENCES permissions (name) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission)
+);
+
+-- Roles of users are scoped per application, see app_id claim of the JWT.
+CREATE TABLE user_roles
+(
+    user_id        UUID      NOT NULL REFERENCES users (id),
+    application_id UUID      NOT NULL,
+    role_id        UUID      NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    created_at     TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, application_id, role_id)
+);
+
+CREATE INDEX user_roles_role_id_idx ON user_roles USING btree (role_id);
+
+-- API keys are limited by permissions of their creator in the application
+-- the key was created in.
+ALTER TABLE api_keys ADD COLUMN application_id UUID;
+
+INSERT INTO permissions (name, description)
+VALUES ('users:read', 'Get and list users'),
+       ('users:write', 'Create and update users'),
+       ('users:delete', 'Delete and restore users'),
+       ('roles:write', 'Assign roles to users'),
+       ('apikeys:manage', 'Create, list and revoke own API keys');
+
+INSERT INTO roles (id, name, description)
+VALUES ('0192a6c4-8f00-7000-8000-000000000001', 'admin', 'Full access'),
+       ('0192a6c4-8f00-7000-8000-000000000002', 'editor', 'Manages users'),
+       ('0192a6c4-8f00-7000-8000-000000000003', 'viewer', 'Reads users');
+
+INSERT INTO role_permissions (role_id, permission)
+VALUES ('0192a6c4-8f00-7000-8000-000000000001', 'users:read'),
+       ('0192a6c4-8f00-7000-8000-000000000001', 'users:write'),
+       ('0192a6c4-8f00-7000-8000-000000000001', 'users:delete'),
+       ('0192a6c4-8f00-7000-8000-000000000001', 'roles:write'),
+       ('0192a6c4-8f00-7000-8000-000000000001', 'apikeys:manage'),
+       ('0192a6c4-8f00-7000-8000-000000000002', 'users:read'),
+       ('0192a6c4-8f00-7000-8000-000000000002', 'users:write'),
+       ('0192a6c4-8f00-7000-8000-000000000002', 'apikeys:manage'),
+       ('0192a6c4-8f00-7000-8000-000000000003', 'users:read');
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE api_keys DROP COLUMN IF EXISTS application_id;
+DROP TABLE IF EXISTS user_roles;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS roles;
+DROP TABLE IF EXISTS permissions;
+-- +goose StatementEnd
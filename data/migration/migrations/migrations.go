@@ -0,0 +1,40 @@
+// Package migrations holds SQL migrations embedded by the migration package
+// and Go migrations, which register themselves on import.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"runtime"
+
+	"github.com/pressly/goose/v3"
+
+	"github.com/golang-cz/skeleton/data"
+)
+
+// GoMigration is run within a transaction, db has all stores bound to it.
+type GoMigration func(ctx context.Context, db *data.Database) error
+
+// addMigration registers Go migration defined in the caller's file. The
+// version is taken from the file name, same as for SQL migrations, so both
+// are ordered together.
+func addMigration(up, down GoMigration) {
+	_, filename, _, _ := runtime.Caller(1)
+	goose.AddNamedMigration(filename, withDatabase(up), withDatabase(down))
+}
+
+func withDatabase(fn GoMigration) goose.GoMigration {
+	if fn == nil {
+		return nil
+	}
+
+	return func(tx *sql.Tx) error {
+		db, err := data.NewTx(tx)
+		if err != nil {
+			return fmt.Errorf("create database from transaction: %w", err)
+		}
+
+		return fn(context.Background(), db)
+	}
+}
@@ -0,0 +1,428 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pressly/goose/v3"
+
+	"github.com/golang-cz/skeleton/config"
+	"github.com/golang-cz/skeleton/data"
+)
+
+// MigrationPlan lists migrations which "goose up" would apply.
+type MigrationPlan struct {
+	CurrentVersion int64
+	Migrations     []*PlannedMigration
+}
+
+type PlannedMigration struct {
+	Version    int64
+	Source     string
+	Go         bool
+	NoTx       bool // Can't run within a transaction, so dry run skips it.
+	OutOfOrder bool // Older than the current DB version, "goose up" refuses to apply it.
+	Steps      []*PlanStep
+
+	// Filled in by dry run.
+	Executed bool
+	Duration time.Duration
+
+	migration *goose.Migration
+}
+
+type PlanStep struct {
+	Line    int
+	SQL     string // Empty for Go migrations.
+	Warning string // Heavy lock the statement is expected to take.
+
+	// Filled in by dry run.
+	Duration time.Duration
+	LockWait time.Duration // Sampled, so it's approximate.
+	Locks    []string      // Heavy locks acquired on existing relations.
+	Err      error
+}
+
+// Plan lists pending migrations with their statements and warnings about
+// heavy locks. Nothing is executed.
+func Plan(ctx context.Context, conf *config.Config) (*MigrationPlan, error) {
+	sess, err := data.NewDBSession(conf.DB)
+	if err != nil {
+		return nil, fmt.Errorf("connect to DB: %w", err)
+	}
+	defer sess.Close()
+
+	return plan(ctx, conf, sess.Driver().(*sql.DB))
+}
+
+// DryRun runs pending migrations within a single transaction, which is
+// rolled back at the end, and reports timing, lock waits and heavy locks
+// observed for each statement. Migrations which can't run in a transaction
+// are skipped. Statements wait for locks at most lockTimeout.
+func DryRun(ctx context.Context, conf *config.Config, lockTimeout time.Duration) (*MigrationPlan, error) {
+	sess, err := data.NewDBSession(conf.DB)
+	if err != nil {
+		return nil, fmt.Errorf("connect to DB: %w", err)
+	}
+	defer sess.Close()
+
+	db := sess.Driver().(*sql.DB)
+
+	p, err := plan(ctx, conf, db)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`SET LOCAL lock_timeout = %d`, lockTimeout.Milliseconds())); err != nil {
+		return nil, fmt.Errorf("set lock timeout: %w", err)
+	}
+
+	var pid int
+	if err := tx.QueryRowContext(ctx, `SELECT pg_backend_pid()`).Scan(&pid); err != nil {
+		return nil, fmt.Errorf("get backend pid: %w", err)
+	}
+
+	monitor := startLockMonitor(ctx, db, pid)
+	defer monitor.stop()
+
+	for _, m := range p.Migrations {
+		if m.NoTx || m.OutOfOrder {
+			continue
+		}
+
+		for _, step := range m.Steps {
+			monitor.run(step, func() error {
+				if m.Go {
+					if m.migration.UpFn == nil {
+						return nil
+					}
+					return m.migration.UpFn(tx)
+				}
+
+				_, err := tx.ExecContext(ctx, step.SQL)
+				return err
+			})
+			m.Duration += step.Duration
+
+			if step.Err != nil {
+				// The transaction is aborted, nothing else can run.
+				return p, fmt.Errorf("%s:%d: %w", m.Source, step.Line, step.Err)
+			}
+		}
+
+		m.Executed = true
+	}
+
+	return p, nil
+}
+
+func plan(ctx context.Context, conf *config.Config, db *sql.DB) (*MigrationPlan, error) {
+	if err := goose.SetDialect(conf.Goose.Driver); err != nil {
+		return nil, fmt.Errorf("set dialect: %w", err)
+	}
+
+	collected, err := goose.CollectMigrations("migrations", 0, math.MaxInt64)
+	if err != nil {
+		return nil, fmt.Errorf("collect migrations: %w", err)
+	}
+
+	if err := checkVersions(conf, collected); err != nil {
+		return nil, err
+	}
+
+	applied, current, err := appliedVersions(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("load applied migrations: %w", err)
+	}
+
+	p := &MigrationPlan{CurrentVersion: current}
+	for _, m := range collected {
+		if applied[m.Version] {
+			continue
+		}
+
+		pm := &PlannedMigration{
+			Version:    m.Version,
+			Source:     filepath.Base(m.Source),
+			OutOfOrder: m.Version < current,
+			migration:  m,
+		}
+
+		if filepath.Ext(m.Source) == ".go" {
+			pm.Go = true
+			pm.NoTx = !m.UseTx
+			pm.Steps = []*PlanStep{{}}
+		} else {
+			content, err := migrations.ReadFile(m.Source)
+			if err != nil {
+				return nil, fmt.Errorf("read migration: %w", err)
+			}
+			pm.NoTx, pm.Steps = planSQL(string(content))
+		}
+
+		p.Migrations = append(p.Migrations, pm)
+	}
+
+	return p, nil
+}
+
+// appliedVersions returns applied migration versions and the highest of them.
+// Missing goose table means nothing was applied yet, it's not created here.
+func appliedVersions(ctx context.Context, db *sql.DB) (map[int64]bool, int64, error) {
+	applied := map[int64]bool{}
+
+	var exists bool
+	if err := db.QueryRowContext(ctx, `SELECT to_regclass($1) IS NOT NULL`, goose.TableName()).Scan(&exists); err != nil {
+		return nil, 0, err
+	}
+	if !exists {
+		return applied, 0, nil
+	}
+
+	var current int64
+	err := queryRows(ctx, db, fmt.Sprintf(`
+		SELECT DISTINCT ON (version_id) version_id, is_applied
+		FROM %s
+		ORDER BY version_id, id DESC
+	`, pq.QuoteIdentifier(goose.TableName())), func(rows *sql.Rows) error {
+		var (
+			version   int64
+			isApplied bool
+		)
+		if err := rows.Scan(&version, &isApplied); err != nil {
+			return err
+		}
+		if isApplied {
+			applied[version] = true
+			current = max(current, version)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return applied, current, nil
+}
+
+func planSQL(content string) (noTx bool, steps []*PlanStep) {
+	for _, line := range strings.Split(content, "\n") {
+		if annotation(line) == "+goose NO TRANSACTION" {
+			noTx = true
+		}
+	}
+
+	up, _ := upSection(content)
+
+	// Same as for lint, tables created within the migration are empty.
+	created := map[string]bool{}
+	for _, stmt := range splitStatements(up) {
+		steps = append(steps, &PlanStep{
+			Line:    stmt.line,
+			SQL:     stmt.raw,
+			Warning: lockWarning(stmt.sql, created),
+		})
+	}
+
+	return noTx, steps
+}
+
+var (
+	reValidateConstraint = regexp.MustCompile(`(?i)^VALIDATE\s+CONSTRAINT\b`)
+	reForeignKey         = regexp.MustCompile(`(?i)\bFOREIGN\s+KEY\b`)
+
+	// Statements taking ACCESS EXCLUSIVE lock on the captured table. REFRESH
+	// MATERIALIZED VIEW CONCURRENTLY captures the keyword and is skipped.
+	reAccessExclusive = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)^DROP\s+TABLE\s+(?:IF\s+EXISTS\s+)?([\w."]+)`),
+		regexp.MustCompile(`(?i)^TRUNCATE\s+(?:TABLE\s+)?(?:ONLY\s+)?([\w."]+)`),
+		regexp.MustCompile(`(?i)^LOCK\s+(?:TABLE\s+)?(?:ONLY\s+)?([\w."]+)`),
+		regexp.MustCompile(`(?i)^CLUSTER\s+([\w."]+)`),
+		regexp.MustCompile(`(?i)^REFRESH\s+MATERIALIZED\s+VIEW\s+([\w."]+)`),
+	}
+)
+
+// lockWarning describes heavy lock the statement takes on an existing table,
+// ie. a lock conflicting with regular writes.
+func lockWarning(sql string, created map[string]bool) string {
+	if m := reCreateTable.FindStringSubmatch(sql); m != nil {
+		created[tableName(m[1])] = true
+		return ""
+	}
+
+	if m := reCreateIndex.FindStringSubmatch(sql); m != nil {
+		if m[1] == "" && !created[tableName(m[2])] {
+			return fmt.Sprintf("SHARE lock on %s blocks writes until the index is built", tableName(m[2]))
+		}
+		return ""
+	}
+
+	if m := reAlterTable.FindStringSubmatch(sql); m != nil {
+		table := tableName(m[1])
+		if created[table] {
+			return ""
+		}
+
+		var mode string
+		for _, action := range splitTopLevel(sql[len(m[0]):], ',') {
+			action = strings.TrimSpace(action)
+			switch {
+			case reValidateConstraint.MatchString(action):
+				// SHARE UPDATE EXCLUSIVE doesn't block reads nor writes.
+			case reAddConstraint.MatchString(action) && reForeignKey.MatchString(action):
+				if mode == "" {
+					mode = "SHARE ROW EXCLUSIVE"
+				}
+			default:
+				mode = "ACCESS EXCLUSIVE"
+			}
+		}
+
+		switch mode {
+		case "ACCESS EXCLUSIVE":
+			return fmt.Sprintf("ACCESS EXCLUSIVE lock on %s blocks reads and writes", table)
+		case "SHARE ROW EXCLUSIVE":
+			return fmt.Sprintf("SHARE ROW EXCLUSIVE lock on %s blocks writes", table)
+		}
+		return ""
+	}
+
+	for _, re := range reAccessExclusive {
+		if m := re.FindStringSubmatch(sql); m != nil {
+			table := tableName(m[1])
+			if created[table] || table == "concurrently" {
+				return ""
+			}
+			return fmt.Sprintf("ACCESS EXCLUSIVE lock on %s blocks reads and writes", table)
+		}
+	}
+
+	return ""
+}
+
+const lockPollInterval = 10 * time.Millisecond
+
+// lockMonitor watches the dry run transaction from a separate connection.
+type lockMonitor struct {
+	db     *sql.DB
+	pid    int
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu       sync.Mutex
+	lockWait time.Duration
+	seen     map[string]bool
+}
+
+func startLockMonitor(ctx context.Context, db *sql.DB, pid int) *lockMonitor {
+	ctx, cancel := context.WithCancel(ctx)
+
+	m := &lockMonitor{
+		db:     db,
+		pid:    pid,
+		cancel: cancel,
+		done:   make(chan struct{}),
+		seen:   map[string]bool{},
+	}
+
+	go m.poll(ctx)
+
+	return m
+}
+
+func (m *lockMonitor) stop() {
+	m.cancel()
+	<-m.done
+}
+
+// poll samples whether the transaction is waiting for a lock.
+func (m *lockMonitor) poll(ctx context.Context) {
+	defer close(m.done)
+
+	ticker := time.NewTicker(lockPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		var waiting bool
+		err := m.db.QueryRowContext(ctx, `SELECT wait_event_type IS NOT DISTINCT FROM 'Lock' FROM pg_catalog.pg_stat_activity WHERE pid = $1`, m.pid).Scan(&waiting)
+		if err != nil || !waiting {
+			continue
+		}
+
+		m.mu.Lock()
+		m.lockWait += lockPollInterval
+		m.mu.Unlock()
+	}
+}
+
+// run executes fn and records its duration, lock waits and heavy locks it
+// acquired into step.
+func (m *lockMonitor) run(step *PlanStep, fn func() error) {
+	m.mu.Lock()
+	m.lockWait = 0
+	m.mu.Unlock()
+
+	start := time.Now()
+	step.Err = fn()
+	step.Duration = time.Since(start)
+
+	m.mu.Lock()
+	step.LockWait = m.lockWait
+	m.mu.Unlock()
+
+	locks, err := m.newLocks()
+	if err != nil {
+		step.Err = errors.Join(step.Err, fmt.Errorf("load locks: %w", err))
+	}
+	step.Locks = locks
+}
+
+// newLocks returns heavy locks held by the transaction, which weren't seen
+// before. Relations created by the transaction aren't visible to the
+// monitor's connection, so locks on them are left out.
+func (m *lockMonitor) newLocks() ([]string, error) {
+	var locks []string
+	err := queryRows(context.Background(), m.db, fmt.Sprintf(`
+		SELECT c.relname, l.mode
+		FROM pg_catalog.pg_locks l
+		JOIN pg_catalog.pg_class c ON c.oid = l.relation
+		WHERE l.pid = %d AND l.locktype = 'relation' AND l.granted
+			AND l.mode IN ('ShareLock', 'ShareRowExclusiveLock', 'ExclusiveLock', 'AccessExclusiveLock')
+		ORDER BY c.relname, l.mode
+	`, m.pid), func(rows *sql.Rows) error {
+		var relation, mode string
+		if err := rows.Scan(&relation, &mode); err != nil {
+			return err
+		}
+
+		lock := fmt.Sprintf("%s on %s", mode, relation)
+		if !m.seen[lock] {
+			m.seen[lock] = true
+			locks = append(locks, lock)
+		}
+		return nil
+	})
+
+	return locks, err
+}
@@ -0,0 +1,490 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/pressly/goose/v3"
+
+	"github.com/golang-cz/skeleton/config"
+	"github.com/golang-cz/skeleton/data"
+	"github.com/golang-cz/skeleton/internal/guuid"
+)
+
+// Schema is a normalized description of the public schema, introspected
+// from pg_catalog. It doesn't depend on how the schema was created, so
+// a migrated database and db/schema.sql can be compared.
+type Schema struct {
+	Extensions []string
+	Sequences  []Sequence
+	Tables     []*Table
+}
+
+type Sequence struct {
+	Name    string
+	OwnedBy string // table.column
+}
+
+type Table struct {
+	Name        string
+	Columns     []Column
+	Constraints []Constraint
+	Indexes     []Index
+}
+
+type Column struct {
+	Name    string
+	Type    string
+	NotNull bool
+	Default string
+}
+
+func (c Column) String() string {
+	def := c.Type
+	if c.Default != "" {
+		def += " DEFAULT " + c.Default
+	}
+	if c.NotNull {
+		def += " NOT NULL"
+	}
+	return def
+}
+
+type Constraint struct {
+	Name       string
+	Definition string
+	ForeignKey bool
+}
+
+type Index struct {
+	Name       string
+	Definition string
+}
+
+// DumpSchema applies all migrations to a scratch database and returns its schema.
+func DumpSchema(ctx context.Context, conf *config.Config) (*Schema, error) {
+	var schema *Schema
+	err := withScratchDB(ctx, conf, "migrations", func(conf *config.Config) error {
+		if err := RunMigrations([]string{"up"}, conf); err != nil {
+			return fmt.Errorf("run migrations: %w", err)
+		}
+
+		var err error
+		schema, err = introspectDB(ctx, conf.DB)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("dump migrated schema: %w", err)
+	}
+
+	return schema, nil
+}
+
+// LoadSchemaFile loads given SQL file into a scratch database and returns its schema.
+func LoadSchemaFile(ctx context.Context, conf *config.Config, path string) (*Schema, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read schema file: %w", err)
+	}
+
+	var schema *Schema
+	err = withScratchDB(ctx, conf, "file", func(conf *config.Config) error {
+		// The file might change session settings (eg. search_path), so it's
+		// loaded over a separate connection.
+		if err := execSQL(ctx, conf.DB, string(content)); err != nil {
+			return fmt.Errorf("load %s: %w", path, err)
+		}
+
+		var err error
+		schema, err = introspectDB(ctx, conf.DB)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load schema file: %w", err)
+	}
+
+	return schema, nil
+}
+
+// withScratchDB creates a temporary database and runs fn with config
+// pointing to it. The database is dropped afterwards.
+func withScratchDB(ctx context.Context, conf *config.Config, name string, fn func(conf *config.Config) error) error {
+	database := fmt.Sprintf("%s_schema_%s_%s", conf.DB.Database, name, strings.ReplaceAll(guuid.NewV7().String(), "-", "")[20:])
+
+	maintenanceConf := conf.DB
+	maintenanceConf.Database = "postgres"
+	maintenance, err := data.NewDBSession(maintenanceConf)
+	if err != nil {
+		return fmt.Errorf("connect to maintenance DB: %w", err)
+	}
+	defer maintenance.Close()
+
+	if _, err := maintenance.Driver().(*sql.DB).ExecContext(ctx, fmt.Sprintf(`CREATE DATABASE %s`, pq.QuoteIdentifier(database))); err != nil {
+		return fmt.Errorf("create scratch DB %q: %w", database, err)
+	}
+	defer maintenance.Driver().(*sql.DB).ExecContext(ctx, fmt.Sprintf(`DROP DATABASE IF EXISTS %s WITH (FORCE)`, pq.QuoteIdentifier(database)))
+
+	scratchConf := *conf
+	scratchConf.DB.Database = database
+
+	return fn(&scratchConf)
+}
+
+func execSQL(ctx context.Context, conf config.DB, query string) error {
+	sess, err := data.NewDBSession(conf)
+	if err != nil {
+		return fmt.Errorf("connect to DB: %w", err)
+	}
+	defer sess.Close()
+
+	_, err = sess.Driver().(*sql.DB).ExecContext(ctx, query)
+	return err
+}
+
+func introspectDB(ctx context.Context, conf config.DB) (*Schema, error) {
+	sess, err := data.NewDBSession(conf)
+	if err != nil {
+		return nil, fmt.Errorf("connect to DB: %w", err)
+	}
+	defer sess.Close()
+
+	return Introspect(ctx, sess.Driver().(*sql.DB))
+}
+
+// Introspect reads the public schema of given database from pg_catalog.
+// Goose version table isn't part of the schema, it's created by goose itself.
+func Introspect(ctx context.Context, db *sql.DB) (*Schema, error) {
+	schema := &Schema{}
+	tables := map[string]*Table{}
+
+	err := queryRows(ctx, db, `
+		SELECT extname
+		FROM pg_catalog.pg_extension
+		WHERE extname <> 'plpgsql'
+		ORDER BY extname
+	`, func(rows *sql.Rows) error {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		schema.Extensions = append(schema.Extensions, name)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("introspect extensions: %w", err)
+	}
+
+	err = queryRows(ctx, db, `
+		SELECT s.relname, COALESCE(t.relname || '.' || a.attname, '')
+		FROM pg_catalog.pg_class s
+		JOIN pg_catalog.pg_namespace n ON n.oid = s.relnamespace
+		LEFT JOIN pg_catalog.pg_depend d ON d.objid = s.oid AND d.classid = 'pg_catalog.pg_class'::regclass AND d.deptype = 'a'
+		LEFT JOIN pg_catalog.pg_class t ON t.oid = d.refobjid
+		LEFT JOIN pg_catalog.pg_attribute a ON a.attrelid = d.refobjid AND a.attnum = d.refobjsubid
+		WHERE n.nspname = 'public' AND s.relkind = 'S'
+		ORDER BY s.relname
+	`, func(rows *sql.Rows) error {
+		var seq Sequence
+		if err := rows.Scan(&seq.Name, &seq.OwnedBy); err != nil {
+			return err
+		}
+		if strings.HasPrefix(seq.OwnedBy, goose.TableName()+".") {
+			return nil
+		}
+		schema.Sequences = append(schema.Sequences, seq)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("introspect sequences: %w", err)
+	}
+
+	err = queryRows(ctx, db, `
+		SELECT c.relname, a.attname, pg_catalog.format_type(a.atttypid, a.atttypmod), a.attnotnull,
+			COALESCE(pg_catalog.pg_get_expr(d.adbin, d.adrelid), '')
+		FROM pg_catalog.pg_attribute a
+		JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_catalog.pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE n.nspname = 'public' AND c.relkind IN ('r', 'p') AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY c.relname, a.attnum
+	`, func(rows *sql.Rows) error {
+		var (
+			tableName string
+			col       Column
+		)
+		if err := rows.Scan(&tableName, &col.Name, &col.Type, &col.NotNull, &col.Default); err != nil {
+			return err
+		}
+		if tableName == goose.TableName() {
+			return nil
+		}
+
+		table, ok := tables[tableName]
+		if !ok {
+			table = &Table{Name: tableName}
+			tables[tableName] = table
+			schema.Tables = append(schema.Tables, table)
+		}
+		table.Columns = append(table.Columns, col)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("introspect columns: %w", err)
+	}
+
+	// NOT NULL constraints are part of the column definitions.
+	err = queryRows(ctx, db, `
+		SELECT c.relname, con.conname, pg_catalog.pg_get_constraintdef(con.oid, true), con.contype = 'f'
+		FROM pg_catalog.pg_constraint con
+		JOIN pg_catalog.pg_class c ON c.oid = con.conrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = 'public' AND con.contype <> 'n'
+		ORDER BY c.relname, con.conname
+	`, func(rows *sql.Rows) error {
+		var (
+			tableName string
+			con       Constraint
+		)
+		if err := rows.Scan(&tableName, &con.Name, &con.Definition, &con.ForeignKey); err != nil {
+			return err
+		}
+		if table, ok := tables[tableName]; ok {
+			table.Constraints = append(table.Constraints, con)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("introspect constraints: %w", err)
+	}
+
+	// Indexes backing constraints are part of the constraint definitions.
+	err = queryRows(ctx, db, `
+		SELECT c.relname, i.relname, pg_catalog.pg_get_indexdef(ix.indexrelid)
+		FROM pg_catalog.pg_index ix
+		JOIN pg_catalog.pg_class i ON i.oid = ix.indexrelid
+		JOIN pg_catalog.pg_class c ON c.oid = ix.indrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = 'public' AND NOT EXISTS (
+			SELECT 1 FROM pg_catalog.pg_constraint con
+			WHERE con.conindid = ix.indexrelid AND con.contype IN ('p', 'u', 'x')
+		)
+		ORDER BY c.relname, i.relname
+	`, func(rows *sql.Rows) error {
+		var (
+			tableName string
+			idx       Index
+		)
+		if err := rows.Scan(&tableName, &idx.Name, &idx.Definition); err != nil {
+			return err
+		}
+		if table, ok := tables[tableName]; ok {
+			table.Indexes = append(table.Indexes, idx)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("introspect indexes: %w", err)
+	}
+
+	sort.Slice(schema.Tables, func(i, j int) bool {
+		return schema.Tables[i].Name < schema.Tables[j].Name
+	})
+
+	return schema, nil
+}
+
+func queryRows(ctx context.Context, db *sql.DB, query string, scan func(rows *sql.Rows) error) error {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return fmt.Errorf("scan: %w", err)
+		}
+	}
+
+	return rows.Err()
+}
+
+// SQL renders the schema as DDL statements.
+func (s *Schema) SQL() string {
+	var b strings.Builder
+
+	b.WriteString("-- Code generated by \"goose schema --write\". DO NOT EDIT.\n")
+
+	for _, ext := range s.Extensions {
+		fmt.Fprintf(&b, "\nCREATE EXTENSION IF NOT EXISTS %s WITH SCHEMA public;\n", pq.QuoteIdentifier(ext))
+	}
+
+	for _, seq := range s.Sequences {
+		fmt.Fprintf(&b, "\nCREATE SEQUENCE public.%s;\n", seq.Name)
+	}
+
+	for _, t := range s.Tables {
+		fmt.Fprintf(&b, "\nCREATE TABLE public.%s (\n", t.Name)
+		for i, c := range t.Columns {
+			sep := ","
+			if i == len(t.Columns)-1 {
+				sep = ""
+			}
+			fmt.Fprintf(&b, "    %s %s%s\n", c.Name, c, sep)
+		}
+		b.WriteString(");\n")
+	}
+
+	for _, seq := range s.Sequences {
+		if seq.OwnedBy != "" {
+			fmt.Fprintf(&b, "\nALTER SEQUENCE public.%s OWNED BY public.%s;\n", seq.Name, seq.OwnedBy)
+		}
+	}
+
+	// Foreign keys go last, they need the referenced constraints.
+	for _, fk := range []bool{false, true} {
+		for _, t := range s.Tables {
+			for _, c := range t.Constraints {
+				if c.ForeignKey == fk {
+					fmt.Fprintf(&b, "\nALTER TABLE ONLY public.%s\n    ADD CONSTRAINT %s %s;\n", t.Name, c.Name, c.Definition)
+				}
+			}
+		}
+	}
+
+	for _, t := range s.Tables {
+		for _, idx := range t.Indexes {
+			fmt.Fprintf(&b, "\n%s;\n", idx.Definition)
+		}
+	}
+
+	return b.String()
+}
+
+// DiffSchemas returns human readable differences between two schemas. Lines
+// starting with "-" are only in the old schema, "+" only in the new one and
+// "~" mark changed definitions.
+func DiffSchemas(old, new *Schema) []string {
+	var diff []string
+
+	diff = append(diff, diffSets("extension", old.Extensions, new.Extensions)...)
+
+	oldSeqs, newSeqs := []string{}, []string{}
+	for _, seq := range old.Sequences {
+		oldSeqs = append(oldSeqs, seq.Name)
+	}
+	for _, seq := range new.Sequences {
+		newSeqs = append(newSeqs, seq.Name)
+	}
+	diff = append(diff, diffSets("sequence", oldSeqs, newSeqs)...)
+
+	oldTables := map[string]*Table{}
+	for _, t := range old.Tables {
+		oldTables[t.Name] = t
+	}
+	newTables := map[string]*Table{}
+	for _, t := range new.Tables {
+		newTables[t.Name] = t
+	}
+
+	for _, name := range sortedKeys(oldTables, newTables) {
+		oldTable, newTable := oldTables[name], newTables[name]
+		switch {
+		case newTable == nil:
+			diff = append(diff, fmt.Sprintf("- table %s", name))
+			continue
+		case oldTable == nil:
+			diff = append(diff, fmt.Sprintf("+ table %s", name))
+			for _, c := range newTable.Columns {
+				diff = append(diff, fmt.Sprintf("+ column %s.%s %s", name, c.Name, c))
+			}
+			continue
+		}
+
+		oldCols, newCols := map[string]string{}, map[string]string{}
+		for _, c := range oldTable.Columns {
+			oldCols[c.Name] = c.String()
+		}
+		for _, c := range newTable.Columns {
+			newCols[c.Name] = c.String()
+		}
+		diff = append(diff, diffDefinitions("column "+name, oldCols, newCols)...)
+
+		oldCons, newCons := map[string]string{}, map[string]string{}
+		for _, c := range oldTable.Constraints {
+			oldCons[c.Name] = c.Definition
+		}
+		for _, c := range newTable.Constraints {
+			newCons[c.Name] = c.Definition
+		}
+		diff = append(diff, diffDefinitions("constraint "+name, oldCons, newCons)...)
+
+		oldIdxs, newIdxs := map[string]string{}, map[string]string{}
+		for _, idx := range oldTable.Indexes {
+			oldIdxs[idx.Name] = idx.Definition
+		}
+		for _, idx := range newTable.Indexes {
+			newIdxs[idx.Name] = idx.Definition
+		}
+		diff = append(diff, diffDefinitions("index "+name, oldIdxs, newIdxs)...)
+	}
+
+	return diff
+}
+
+func diffSets(kind string, old, new []string) []string {
+	oldSet, newSet := map[string]string{}, map[string]string{}
+	for _, v := range old {
+		oldSet[v] = ""
+	}
+	for _, v := range new {
+		newSet[v] = ""
+	}
+
+	var diff []string
+	for _, name := range sortedKeys(oldSet, newSet) {
+		if _, ok := newSet[name]; !ok {
+			diff = append(diff, fmt.Sprintf("- %s %s", kind, name))
+		} else if _, ok := oldSet[name]; !ok {
+			diff = append(diff, fmt.Sprintf("+ %s %s", kind, name))
+		}
+	}
+	return diff
+}
+
+func diffDefinitions(prefix string, old, new map[string]string) []string {
+	var diff []string
+	for _, name := range sortedKeys(old, new) {
+		oldDef, inOld := old[name]
+		newDef, inNew := new[name]
+		switch {
+		case !inNew:
+			diff = append(diff, fmt.Sprintf("- %s.%s %s", prefix, name, oldDef))
+		case !inOld:
+			diff = append(diff, fmt.Sprintf("+ %s.%s %s", prefix, name, newDef))
+		case oldDef != newDef:
+			diff = append(diff, fmt.Sprintf("~ %s.%s\n    - %s\n    + %s", prefix, name, oldDef, newDef))
+		}
+	}
+	return diff
+}
+
+func sortedKeys[V any](maps ...map[string]V) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, m := range maps {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,49 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+
+	"github.com/pressly/goose/v3"
+)
+
+// SchemaStatus compares migrations applied to the DB with the ones embedded
+// in the binary.
+type SchemaStatus struct {
+	CurrentVersion int64
+	LatestVersion  int64 // Highest version embedded in the binary.
+	Pending        int
+}
+
+func (s *SchemaStatus) IsCurrent() bool {
+	return s.Pending == 0
+}
+
+func (s *SchemaStatus) String() string {
+	return fmt.Sprintf("version %d, %d pending migration(s), latest %d", s.CurrentVersion, s.Pending, s.LatestVersion)
+}
+
+// Status reports how many of the embedded migrations aren't applied yet.
+func Status(ctx context.Context, db *sql.DB) (*SchemaStatus, error) {
+	collected, err := goose.CollectMigrations("migrations", 0, math.MaxInt64)
+	if err != nil {
+		return nil, fmt.Errorf("collect migrations: %w", err)
+	}
+
+	applied, current, err := appliedVersions(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("load applied migrations: %w", err)
+	}
+
+	status := &SchemaStatus{CurrentVersion: current}
+	for _, m := range collected {
+		status.LatestVersion = max(status.LatestVersion, m.Version)
+		if !applied[m.Version] {
+			status.Pending++
+		}
+	}
+
+	return status, nil
+}
@@ -0,0 +1,154 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/upper/db/v4"
+
+	"github.com/golang-cz/skeleton/pkg/rbac"
+	"github.com/golang-cz/skeleton/pkg/utc"
+)
+
+// Role grants permissions to users it's assigned to. Roles and their
+// permissions are managed by migrations.
+type Role struct {
+	ID          uuid.UUID `db:"id,omitempty,pk"`
+	Name        string    `db:"name"`
+	Description string    `db:"description"`
+}
+
+type RoleStore struct {
+	db.Collection
+}
+
+// Interface checks
+var _ = interface {
+	db.Record
+}(&Role{})
+
+var _ = interface {
+	db.Store
+	rbac.Store
+}(&RoleStore{})
+
+func Roles(sess db.Session) *RoleStore {
+	return &RoleStore{sess.Collection("roles")}
+}
+
+func (r *Role) Store(sess db.Session) db.Store {
+	return Roles(sess)
+}
+
+func (s RoleStore) FindByName(name string) (role *Role, err error) {
+	if err = s.Find(db.Cond{"name": name}).One(&role); err != nil {
+		return nil, fmt.Errorf("get first record: %w", err)
+	}
+
+	return role, nil
+}
+
+// Grants returns roles of the user in the application and permissions
+// given by them.
+func (s RoleStore) Grants(ctx context.Context, userId, applicationId uuid.UUID) (*rbac.Grants, error) {
+	sess := s.Session().WithContext(ctx)
+
+	rows, err := sess.SQL().Query(`
+		SELECT r.name, rp.permission
+		FROM user_roles ur
+		JOIN roles r ON r.id = ur.role_id
+		LEFT JOIN role_permissions rp ON rp.role_id = r.id
+		WHERE ur.user_id = ? AND ur.application_id = ?`,
+		userId, applicationId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query grants: %w", err)
+	}
+	defer rows.Close()
+
+	grants := &rbac.Grants{Roles: []string{}, Permissions: []string{}}
+	for rows.Next() {
+		var role string
+		var permission *string
+		if err := rows.Scan(&role, &permission); err != nil {
+			return nil, fmt.Errorf("scan grants: %w", err)
+		}
+		grants.Roles = append(grants.Roles, role)
+		if permission != nil {
+			grants.Permissions = append(grants.Permissions, *permission)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query grants: %w", err)
+	}
+
+	slices.Sort(grants.Roles)
+	grants.Roles = slices.Compact(grants.Roles)
+	slices.Sort(grants.Permissions)
+	grants.Permissions = slices.Compact(grants.Permissions)
+
+	return grants, nil
+}
+
+// Assign assigns the role to the user in the application. Assigning a role
+// twice is no-op.
+func (s RoleStore) Assign(userId, applicationId uuid.UUID, roleName string) error {
+	role, err := s.roleByName(roleName)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Session().SQL().Exec(`
+		INSERT INTO user_roles (user_id, application_id, role_id, created_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT DO NOTHING`,
+		userId, applicationId, role.ID, utc.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("assign role %q to user %v: %w", roleName, userId, err)
+	}
+
+	return nil
+}
+
+// Unassign removes the role from the user in the application. It returns
+// db.ErrNoMoreRows if the role wasn't assigned.
+func (s RoleStore) Unassign(userId, applicationId uuid.UUID, roleName string) error {
+	role, err := s.roleByName(roleName)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.Session().SQL().
+		DeleteFrom("user_roles").
+		Where(db.Cond{"user_id": userId, "application_id": applicationId, "role_id": role.ID}).
+		Exec()
+	if err != nil {
+		return fmt.Errorf("unassign role %q from user %v: %w", roleName, userId, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("role %q of user %v: %w", roleName, userId, db.ErrNoMoreRows)
+	}
+
+	return nil
+}
+
+// roleByName finds the role, unknown roles are invalid input.
+func (s RoleStore) roleByName(name string) (*Role, error) {
+	role, err := s.FindByName(name)
+	if err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return nil, Invalidf("unknown role %q", name)
+		}
+		return nil, err
+	}
+
+	return role, nil
+}
@@ -1,22 +1,63 @@
 package data
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/mail"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gofrs/uuid/v5"
+	"github.com/jackc/pgconn"
 	"github.com/upper/db/v4"
 
+	"github.com/golang-cz/skeleton/internal/guuid"
+	"github.com/golang-cz/skeleton/pkg/keyring"
 	"github.com/golang-cz/skeleton/pkg/utc"
 	"github.com/golang-cz/skeleton/proto"
+	"github.com/golang-cz/skeleton/proto/types"
+)
+
+const (
+	userNameMaxLength = 255
+
+	// Unique index of emails of active users.
+	usersEmailIndexConstraint = "users_active_email_index_key"
+)
+
+var (
+	ErrEmailTaken    = errors.New("email is already taken")
+	ErrInvalidCursor = Invalidf("invalid cursor")
+)
+
+// UserSortColumns are columns users can be sorted by, by API field name.
+// Encrypted columns can't be sorted by.
+var UserSortColumns = map[string]string{
+	"createdAt": "created_at",
+	"updatedAt": "updated_at",
+}
+
+// User statuses to filter by.
+const (
+	UserStatusActive  = "active"
+	UserStatusDeleted = "deleted"
+	UserStatusAll     = "all"
 )
 
 type User struct {
 	*proto.User
 
-	CreatedAt time.Time  `json:"createdAt"           db:"created_at"`
-	UpdatedAt time.Time  `json:"updatedAt"           db:"updated_at"`
-	DeletedAt *time.Time `json:"deletedAt,omitempty" db:"deleted_at"`
+	// PII columns, encrypted with the default keyring. Use the proto.User
+	// fields for reading and writing, these are kept in sync by the store.
+	EncryptedEmail     types.Encrypted[string] `json:"-"                   db:"email"`
+	EncryptedFirstname types.Encrypted[string] `json:"-"                   db:"firstname"`
+	EncryptedLastname  types.Encrypted[string] `json:"-"                   db:"lastname"`
+	EmailIndex         types.BlindIndex        `json:"-"                   db:"email_index"`
+	EncryptionKeyId    *string                 `json:"-"                   db:"encryption_key_id"`
 }
 
 type UserStore struct {
@@ -47,6 +88,10 @@ func (u *User) BeforeCreate(sess db.Session) error {
 		return fmt.Errorf("user is not valid: %w", err)
 	}
 
+	if err := u.encrypt(); err != nil {
+		return fmt.Errorf("encrypt user: %w", err)
+	}
+
 	u.CreatedAt = utc.Now()
 	u.UpdatedAt = u.CreatedAt
 
@@ -58,15 +103,71 @@ func (u *User) BeforeUpdate(sess db.Session) error {
 		return fmt.Errorf("user is not valid: %w", err)
 	}
 
+	if err := u.encrypt(); err != nil {
+		return fmt.Errorf("encrypt user: %w", err)
+	}
+
 	u.UpdatedAt = utc.Now()
 
 	return nil
 }
 
 func (u *User) Validate() error {
+	if u.User == nil {
+		return Invalidf("user is required")
+	}
+	if u.Email == "" {
+		return Invalidf("email is required")
+	}
+	if addr, err := mail.ParseAddress(u.Email); err != nil || addr.Address != u.Email {
+		return Invalidf("email %q is not valid", u.Email)
+	}
+	if utf8.RuneCountInString(u.Firstname) > userNameMaxLength {
+		return Invalidf("firstname is longer than %d characters", userNameMaxLength)
+	}
+	if utf8.RuneCountInString(u.Lastname) > userNameMaxLength {
+		return Invalidf("lastname is longer than %d characters", userNameMaxLength)
+	}
 	return nil
 }
 
+// encrypt copies PII from proto.User into the encrypted columns.
+func (u *User) encrypt() error {
+	if u.User == nil {
+		u.User = &proto.User{}
+	}
+
+	emailIndex, err := types.NewBlindIndex(normalizeEmail(u.User.Email))
+	if err != nil {
+		return fmt.Errorf("email index: %w", err)
+	}
+
+	keyId := keyring.ActiveKeyId()
+
+	u.EncryptedEmail = types.NewEncrypted(u.User.Email)
+	u.EncryptedFirstname = types.NewEncrypted(u.User.Firstname)
+	u.EncryptedLastname = types.NewEncrypted(u.User.Lastname)
+	u.EmailIndex = emailIndex
+	u.EncryptionKeyId = &keyId
+
+	return nil
+}
+
+// decrypt copies PII from the encrypted columns into proto.User.
+func (u *User) decrypt() {
+	if u.User == nil {
+		u.User = &proto.User{}
+	}
+
+	u.User.Email = u.EncryptedEmail.Get()
+	u.User.Firstname = u.EncryptedFirstname.Get()
+	u.User.Lastname = u.EncryptedLastname.Get()
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
 func (s UserStore) Find(conds ...interface{}) db.Result {
 	return s.Collection.Find(conds...)
 }
@@ -80,6 +181,8 @@ func (s UserStore) FindOne(conds ...interface{}) (user *User, err error) {
 		return nil, fmt.Errorf("get first record: %w", err)
 	}
 
+	user.decrypt()
+
 	return user, nil
 }
 
@@ -88,6 +191,8 @@ func (s UserStore) FindActiveOne(conds ...interface{}) (user *User, err error) {
 		return nil, fmt.Errorf("get first record: %w", err)
 	}
 
+	user.decrypt()
+
 	return user, nil
 }
 
@@ -98,3 +203,285 @@ func (s UserStore) FindById(id uuid.UUID, conds ...interface{}) (user *User, err
 func (s UserStore) FindActiveById(id uuid.UUID, conds ...interface{}) (user *User, err error) {
 	return s.FindActiveOne(append([]interface{}{db.Cond{"id": id}}, conds...)...)
 }
+
+// EmailCond returns condition matching users by email through its blind index.
+func (s UserStore) EmailCond(email string) (db.Cond, error) {
+	emailIndex, err := types.NewBlindIndex(normalizeEmail(email))
+	if err != nil {
+		return nil, fmt.Errorf("email index: %w", err)
+	}
+
+	return db.Cond{"email_index": emailIndex}, nil
+}
+
+func (s UserStore) FindByEmail(email string, conds ...interface{}) (user *User, err error) {
+	emailCond, err := s.EmailCond(email)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.FindOne(append([]interface{}{emailCond}, conds...)...)
+}
+
+func (s UserStore) FindActiveByEmail(email string, conds ...interface{}) (user *User, err error) {
+	emailCond, err := s.EmailCond(email)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.FindActiveOne(append([]interface{}{emailCond}, conds...)...)
+}
+
+// Create saves a new user. It returns ErrEmailTaken if an active user has
+// the same email.
+func (s UserStore) Create(user *User) error {
+	if user.User != nil && user.ID.IsNil() {
+		user.ID = guuid.NewV7()
+	}
+
+	if err := s.Session().Save(user); err != nil {
+		return userSaveError(err)
+	}
+
+	return nil
+}
+
+// Update saves changes of the user. It returns ErrEmailTaken if another
+// active user has the same email.
+func (s UserStore) Update(user *User) error {
+	if err := s.Session().Save(user); err != nil {
+		return userSaveError(err)
+	}
+
+	return nil
+}
+
+// SoftDelete marks the active user as deleted.
+func (s UserStore) SoftDelete(id uuid.UUID) error {
+	res := s.FindActive(db.Cond{"id": id})
+
+	count, err := res.Count()
+	if err != nil {
+		return fmt.Errorf("count: %w", err)
+	}
+	if count == 0 {
+		return fmt.Errorf("delete %v: %w", id, db.ErrNoMoreRows)
+	}
+
+	now := utc.Now()
+	if err := res.Update(map[string]interface{}{"deleted_at": now, "updated_at": now}); err != nil {
+		return fmt.Errorf("delete %v: %w", id, err)
+	}
+
+	return nil
+}
+
+// Restore undoes SoftDelete. It returns ErrEmailTaken if an active user has
+// the same email meanwhile.
+func (s UserStore) Restore(id uuid.UUID) (*User, error) {
+	res := s.Find(db.Cond{"id": id, "deleted_at": db.IsNotNull()})
+
+	count, err := res.Count()
+	if err != nil {
+		return nil, fmt.Errorf("count: %w", err)
+	}
+	if count == 0 {
+		return nil, fmt.Errorf("restore %v: %w", id, db.ErrNoMoreRows)
+	}
+
+	if err := res.Update(map[string]interface{}{"deleted_at": nil, "updated_at": utc.Now()}); err != nil {
+		return nil, fmt.Errorf("restore %v: %w", id, userSaveError(err))
+	}
+
+	return s.FindById(id)
+}
+
+// userSaveError translates unique violation of the email index.
+func userSaveError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == usersEmailIndexConstraint {
+		return ErrEmailTaken
+	}
+	return err
+}
+
+// UserCursor points after the last user of a page. Sort is part of it, so
+// the cursor can't be used with another sort.
+type UserCursor struct {
+	Sort  string    `json:"s"`
+	Value time.Time `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+// Encode returns opaque string of the cursor for clients.
+func (c *UserCursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeUserCursor parses cursor returned by Encode.
+func DecodeUserCursor(cursor string) (*UserCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c UserCursor
+	if err := json.Unmarshal(b, &c); err != nil || c.ID.IsNil() {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
+
+// UserListQuery selects a page of users.
+type UserListQuery struct {
+	Email         string
+	Status        string // One of UserStatus*, active by default.
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+
+	// Field of UserSortColumns, "-" prefix sorts in descending order.
+	Sort  string
+	After *UserCursor
+	Limit int
+}
+
+// List returns a page of users and cursor of the next page, nil on the last
+// page. Pages are sorted by the column and id, so they're stable even if
+// users are added meanwhile.
+func (s UserStore) List(q UserListQuery) ([]*User, *UserCursor, error) {
+	field, desc := strings.CutPrefix(q.Sort, "-")
+	column, ok := UserSortColumns[field]
+	if !ok {
+		return nil, nil, Invalidf("can't sort by %q", q.Sort)
+	}
+	if q.After != nil && q.After.Sort != q.Sort {
+		return nil, nil, ErrInvalidCursor
+	}
+
+	conds := []db.LogicalExpr{}
+	switch q.Status {
+	case "", UserStatusActive:
+		conds = append(conds, db.Cond{"deleted_at": db.IsNull()})
+	case UserStatusDeleted:
+		conds = append(conds, db.Cond{"deleted_at": db.IsNotNull()})
+	case UserStatusAll:
+	default:
+		return nil, nil, fmt.Errorf("unknown status %q", q.Status)
+	}
+	if q.Email != "" {
+		emailCond, err := s.EmailCond(q.Email)
+		if err != nil {
+			return nil, nil, err
+		}
+		conds = append(conds, emailCond)
+	}
+	if q.CreatedAfter != nil {
+		conds = append(conds, db.Cond{"created_at >=": q.CreatedAfter.UTC()})
+	}
+	if q.CreatedBefore != nil {
+		conds = append(conds, db.Cond{"created_at <": q.CreatedBefore.UTC()})
+	}
+
+	orderBy := []interface{}{column, "id"}
+	cmp := ">"
+	if desc {
+		orderBy = []interface{}{"-" + column, "-id"}
+		cmp = "<"
+	}
+	if q.After != nil {
+		conds = append(conds, db.Raw(fmt.Sprintf("(%s, id) %s (?, ?)", column, cmp), q.After.Value, q.After.ID))
+	}
+
+	var users []*User
+	// One more user tells there's a next page.
+	if err := s.Find(db.And(conds...)).OrderBy(orderBy...).Limit(q.Limit + 1).All(&users); err != nil {
+		return nil, nil, fmt.Errorf("get all records: %w", err)
+	}
+
+	var next *UserCursor
+	if len(users) > q.Limit {
+		users = users[:q.Limit]
+		last := users[len(users)-1]
+		next = &UserCursor{Sort: q.Sort, ID: last.ID, Value: last.CreatedAt}
+		if column == "updated_at" {
+			next.Value = last.UpdatedAt
+		}
+	}
+
+	for _, user := range users {
+		user.decrypt()
+	}
+
+	return users, next, nil
+}
+
+// Reencrypt re-encrypts up to batchSize users not encrypted with the active
+// keyring key, including rows stored in plaintext. It returns number of
+// processed users; zero means all users are up to date. Without keyring it
+// only fails if there are users to encrypt, so empty databases can be
+// migrated without one.
+func (s UserStore) Reencrypt(ctx context.Context, batchSize int) (int, error) {
+	activeKeyId := keyring.ActiveKeyId()
+	if activeKeyId == "" {
+		count, err := s.Session().WithContext(ctx).Collection("users").Find().Count()
+		if err != nil {
+			return 0, fmt.Errorf("count users: %w", err)
+		}
+		if count == 0 {
+			return 0, nil
+		}
+
+		return 0, keyring.ErrNotConfigured
+	}
+
+	var count int
+	err := TxContext(ctx, s.Session(), func(sess db.Session) error {
+		var users []*User
+		err := sess.SQL().
+			SelectFrom("users").
+			Where("encryption_key_id IS DISTINCT FROM ?", activeKeyId).
+			OrderBy("id").
+			Limit(batchSize).
+			Amend(func(query string) string {
+				// Let concurrent runs pick up different rows.
+				return query + " FOR UPDATE SKIP LOCKED"
+			}).
+			All(&users)
+		if err != nil {
+			return fmt.Errorf("select users: %w", err)
+		}
+
+		for _, u := range users {
+			u.decrypt()
+			if err := u.encrypt(); err != nil {
+				return fmt.Errorf("encrypt user %v: %w", u.ID, err)
+			}
+
+			// Update the columns directly, re-encryption is not a change of the user.
+			_, err := sess.SQL().
+				Update("users").
+				Set(
+					"email", u.EncryptedEmail,
+					"firstname", u.EncryptedFirstname,
+					"lastname", u.EncryptedLastname,
+					"email_index", u.EmailIndex,
+					"encryption_key_id", u.EncryptionKeyId,
+				).
+				Where("id", u.ID).
+				Exec()
+			if err != nil {
+				return fmt.Errorf("update user %v: %w", u.ID, err)
+			}
+		}
+
+		count = len(users)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("reencrypt users: %w", err)
+	}
+
+	return count, nil
+}
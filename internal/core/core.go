@@ -7,7 +7,9 @@ import (
 	"time"
 
 	"github.com/golang-cz/skeleton/config"
+	"github.com/golang-cz/skeleton/pkg/keyring"
 	"github.com/golang-cz/skeleton/pkg/slogger"
+	"github.com/golang-cz/skeleton/pkg/tracing"
 )
 
 func SetupApp(conf *config.Config, appName, version string) error {
@@ -30,5 +32,17 @@ func SetupApp(conf *config.Config, appName, version string) error {
 
 	slog.SetDefault(logger)
 
+	// Set global tracer provider
+	err = tracing.Setup(conf, appName, version)
+	if err != nil {
+		return fmt.Errorf("setup tracing: %w", err)
+	}
+
+	// Set default keyring for encrypted columns
+	err = keyring.Setup(conf.Encryption)
+	if err != nil {
+		return fmt.Errorf("setup keyring: %w", err)
+	}
+
 	return nil
 }
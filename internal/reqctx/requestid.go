@@ -0,0 +1,36 @@
+package reqctx
+
+import (
+	"github.com/golang-cz/skeleton/internal/guuid"
+)
+
+// RequestIdHeader carries request id in HTTP requests, responses and NATS
+// messages.
+const RequestIdHeader = "X-Request-Id"
+
+const maxRequestIdLength = 128
+
+// NewRequestId generates a new request id.
+func NewRequestId() string {
+	return guuid.NewV7().String()
+}
+
+// ValidRequestId reports whether request id received from a client or
+// another service can be used as is. Only ASCII letters, digits and
+// "-_.:" are allowed, so the id is safe to put into logs and SQL comments.
+func ValidRequestId(requestId string) bool {
+	if requestId == "" || len(requestId) > maxRequestIdLength {
+		return false
+	}
+
+	for _, c := range requestId {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
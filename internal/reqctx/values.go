@@ -10,6 +10,10 @@ import (
 var (
 	userIdKey        ctxKey = "userId"
 	applicationIdKey ctxKey = "applicationId"
+	apiKeyKey        ctxKey = "apiKey"
+	requestIdKey     ctxKey = "requestId"
+	clientCertKey    ctxKey = "clientCert"
+	peerAddrKey      ctxKey = "peerAddr"
 )
 
 type ctxKey string
@@ -37,3 +41,65 @@ func GetApplicationId(ctx context.Context) uuid.UUID {
 func SetApplicationId(ctx context.Context, applicationId uuid.UUID) context.Context {
 	return context.WithValue(ctx, applicationIdKey, applicationId)
 }
+
+// ApiKey identifies a machine caller authenticated by API key.
+type ApiKey struct {
+	Id     uuid.UUID
+	Prefix string
+	Scopes []string
+}
+
+// GetApiKey returns API key the request was authenticated with, or nil.
+func GetApiKey(ctx context.Context) *ApiKey {
+	apiKey, _ := ctx.Value(apiKeyKey).(*ApiKey)
+	return apiKey
+}
+
+func SetApiKey(ctx context.Context, apiKey *ApiKey) context.Context {
+	return context.WithValue(ctx, apiKeyKey, apiKey)
+}
+
+// GetRequestId returns id of the request the context belongs to, or empty
+// string outside of requests.
+func GetRequestId(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdKey).(string)
+	return requestId
+}
+
+func SetRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey, requestId)
+}
+
+// ClientCert identifies a caller by its TLS client certificate, verified
+// against the configured client CA.
+type ClientCert struct {
+	CommonName    string
+	Organizations []string
+	DNSNames      []string
+	URIs          []string // Eg. SPIFFE ids.
+	SerialNumber  string
+	// SHA-256 of the DER certificate, hex encoded.
+	Fingerprint string
+}
+
+// GetClientCert returns client certificate identity of the request, or nil.
+func GetClientCert(ctx context.Context) *ClientCert {
+	clientCert, _ := ctx.Value(clientCertKey).(*ClientCert)
+	return clientCert
+}
+
+func SetClientCert(ctx context.Context, clientCert *ClientCert) context.Context {
+	return context.WithValue(ctx, clientCertKey, clientCert)
+}
+
+// GetPeerAddr returns address of the connection peer, ie. the client or the
+// nearest proxy, or empty string if it wasn't stored. Unlike RemoteAddr
+// rewritten from X-Forwarded-For, it can't be spoofed by the client.
+func GetPeerAddr(ctx context.Context) string {
+	peerAddr, _ := ctx.Value(peerAddrKey).(string)
+	return peerAddr
+}
+
+func SetPeerAddr(ctx context.Context, peerAddr string) context.Context {
+	return context.WithValue(ctx, peerAddrKey, peerAddr)
+}
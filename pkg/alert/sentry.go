@@ -16,6 +16,7 @@ import (
 	"github.com/getsentry/sentry-go"
 
 	"github.com/golang-cz/skeleton/config"
+	"github.com/golang-cz/skeleton/internal/reqctx"
 	"github.com/golang-cz/skeleton/pkg/version"
 )
 
@@ -139,10 +140,13 @@ func sendEvent(ctx context.Context, err error) error {
 
 	ev := newSentryEvent(err)
 
-	// ev.Tags["firstCtxField"] = ctx.Value("firstCtxField").(string)
-	tag := "myOwnTag"
-	ev.Tags[tag] = tag
-	fmt.Println(ev.Tags)
+	// Correlate the event with request logs.
+	if requestId := reqctx.GetRequestId(ctx); requestId != "" {
+		ev.Tags["requestId"] = requestId
+	}
+	if userId := reqctx.GetUserId(ctx); !userId.IsNil() {
+		ev.User.ID = userId.String()
+	}
 
 	hub.CaptureEvent(ev)
 
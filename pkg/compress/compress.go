@@ -0,0 +1,358 @@
+package compress
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/golang-cz/skeleton/config"
+	"github.com/golang-cz/skeleton/internal/reqctx"
+)
+
+// Request attributes set for the request logger, see reqctx.AddAttr. Bytes
+// written by the logger's response writer are the compressed ones.
+const (
+	ContentEncodingAttr   = "contentEncoding"
+	UncompressedBytesAttr = "uncompressedBytes"
+)
+
+// encoder is a pooled compressing writer.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// encoders create writers with a speed favouring level, responses are
+// compressed on the fly.
+var encoders = map[string]func() encoder{
+	"zstd": func() encoder {
+		enc, _ := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault), zstd.WithEncoderConcurrency(1))
+		return zstdEncoder{enc}
+	},
+	"br": func() encoder {
+		return brotli.NewWriterLevel(nil, 4)
+	},
+	"gzip": func() encoder {
+		enc, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+		return enc
+	},
+}
+
+// zstdEncoder adapts Reset, which returns nothing on other encoders.
+type zstdEncoder struct {
+	*zstd.Encoder
+}
+
+func (e zstdEncoder) Reset(w io.Writer) {
+	e.Encoder.Reset(w)
+}
+
+// Middleware compresses responses with the encoding preferred by the
+// server among the ones accepted by the client.
+//
+// A response is compressed only if its media type is allowed, it has at
+// least conf.MinSize bytes and no Content-Encoding of its own, so already
+// compressed content passes through. Mount it after the request logger, so
+// the logger counts compressed bytes.
+func Middleware(conf config.Compression) (func(http.Handler) http.Handler, error) {
+	pools := make(map[string]*sync.Pool, len(conf.Encodings))
+	for _, name := range conf.Encodings {
+		newEncoder, ok := encoders[name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported encoding %q", name)
+		}
+		pools[name] = &sync.Pool{New: func() any { return newEncoder() }}
+	}
+
+	return func(next http.Handler) http.Handler {
+		if !conf.Enabled || len(pools) == 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead || r.Header.Get("Range") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			encoding := Negotiate(r.Header.Get("Accept-Encoding"), conf.Encodings)
+
+			cw := &responseWriter{
+				ResponseWriter: w,
+				ctx:            r.Context(),
+				conf:           &conf,
+				encoding:       encoding,
+				pool:           pools[encoding],
+				status:         http.StatusOK,
+			}
+			defer cw.Close()
+
+			next.ServeHTTP(cw, r)
+		})
+	}, nil
+}
+
+// Negotiate returns the first of supported encodings accepted with non-zero
+// quality, or empty string.
+func Negotiate(acceptEncoding string, supported []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	accepted := map[string]bool{}
+	wildcard := false
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if key == "q" {
+				if v, err := strconv.ParseFloat(value, 64); err == nil {
+					q = v
+				}
+			}
+		}
+
+		if name == "*" {
+			wildcard = q > 0
+			continue
+		}
+		accepted[name] = q > 0
+	}
+
+	for _, name := range supported {
+		ok, listed := accepted[name]
+		if ok || (!listed && wildcard) {
+			return name
+		}
+	}
+	return ""
+}
+
+// responseWriter buffers the first conf.MinSize bytes to decide whether to
+// compress. Headers are written once decided.
+type responseWriter struct {
+	http.ResponseWriter
+	ctx      context.Context
+	conf     *config.Compression
+	encoding string
+	pool     *sync.Pool
+
+	status      int
+	wroteHeader bool
+	decided     bool
+	buf         bytes.Buffer
+	enc         encoder
+	written     int
+	flushed     bool
+	closed      bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	if status >= 100 && status < 200 {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.wroteHeader = true
+	w.status = status
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("compress: write after close")
+	}
+	w.wroteHeader = true
+	w.written += len(p)
+
+	if !w.decided {
+		w.buf.Write(p)
+		if w.buf.Len() < w.conf.MinSize && !w.skip() {
+			return len(p), nil
+		}
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	if w.enc != nil {
+		return w.enc.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// skip reports early, without waiting for enough bytes, that the response
+// won't be compressed.
+func (w *responseWriter) skip() bool {
+	h := w.Header()
+	if w.pool == nil || h.Get("Content-Encoding") != "" {
+		return true
+	}
+	if cl, err := strconv.Atoi(h.Get("Content-Length")); err == nil && cl < w.conf.MinSize {
+		return true
+	}
+	return false
+}
+
+// decide writes headers and the buffered bytes, compressed if the response
+// qualifies.
+func (w *responseWriter) decide() error {
+	w.decided = true
+
+	h := w.Header()
+	if w.compressible() {
+		w.enc = w.pool.Get().(encoder)
+		w.enc.Reset(w.ResponseWriter)
+
+		h.Set("Content-Encoding", w.encoding)
+		h.Del("Content-Length")
+		h.Del("Accept-Ranges")
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+	}
+	if w.varies() {
+		h.Add("Vary", "Accept-Encoding")
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.buf.Len() == 0 {
+		return nil
+	}
+
+	var err error
+	if w.enc != nil {
+		_, err = w.enc.Write(w.buf.Bytes())
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+	w.buf.Reset()
+	return err
+}
+
+func (w *responseWriter) compressible() bool {
+	if w.pool == nil || (w.buf.Len() < w.conf.MinSize && !w.flushed) {
+		return false
+	}
+	if w.status == http.StatusNoContent || w.status == http.StatusNotModified {
+		return false
+	}
+
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" || strings.Contains(h.Get("Cache-Control"), "no-transform") {
+		return false
+	}
+
+	if h.Get("Content-Type") == "" {
+		h.Set("Content-Type", http.DetectContentType(w.buf.Bytes()))
+	}
+	return w.allowedType()
+}
+
+// varies reports whether the response depends on Accept-Encoding, ie.
+// another client could get it compressed.
+func (w *responseWriter) varies() bool {
+	return w.enc != nil || (w.Header().Get("Content-Encoding") == "" && w.allowedType())
+}
+
+func (w *responseWriter) allowedType() bool {
+	mediaType, _, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+
+	for _, allowed := range w.conf.ContentTypes {
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+			continue
+		}
+		if mediaType == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// Close writes what's buffered and finishes the compressed stream.
+func (w *responseWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	if !w.decided {
+		if !w.wroteHeader {
+			// Nothing written, let net/http write the response.
+			return nil
+		}
+		if err := w.decide(); err != nil {
+			return err
+		}
+	}
+	if w.enc == nil {
+		return nil
+	}
+
+	err := w.enc.Close()
+	w.enc.Reset(nil)
+	w.pool.Put(w.enc)
+	w.enc = nil
+
+	reqctx.AddAttr(w.ctx, ContentEncodingAttr, w.encoding)
+	reqctx.AddAttr(w.ctx, UncompressedBytesAttr, w.written)
+
+	return err
+}
+
+// Flush sends what's written so far, eg. for streamed responses, even if
+// it's fewer than conf.MinSize bytes.
+func (w *responseWriter) Flush() {
+	if !w.decided {
+		w.flushed = w.buf.Len() > 0
+		w.wroteHeader = true
+		if err := w.decide(); err != nil {
+			return
+		}
+	}
+	if w.enc != nil {
+		if err := w.enc.Flush(); err != nil {
+			return
+		}
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hj, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hj.Hijack()
+	}
+	return nil, nil, errors.New("compress: underlying response writer is not a hijacker")
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
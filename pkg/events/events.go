@@ -5,4 +5,10 @@ type Event string
 var (
 	EvAPIHealth       = "health.api"
 	EvSchedulerHealth = "health.scheduler"
+
+	// EvMaintenance broadcasts maintenance mode state to API replicas.
+	EvMaintenance = "maintenance.api"
+
+	// EvPermissions invalidates cached permissions of users on API replicas.
+	EvPermissions = "permissions.api"
 )
@@ -0,0 +1,66 @@
+// Package idempotency stores responses of requests sent with Idempotency-Key
+// header, so retried requests get the original response instead of being
+// processed again.
+package idempotency
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Response of the first request with given key.
+type Response struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"contentType"`
+	Body        []byte `json:"body"`
+}
+
+// Record of a key. Response is nil while the first request is in progress.
+type Record struct {
+	RequestHash []byte    `json:"requestHash"`
+	Response    *Response `json:"response,omitempty"`
+}
+
+// ErrReservationLost is returned by Store.Complete if the reservation
+// expired and the key was taken over by another request.
+var ErrReservationLost = errors.New("idempotency: reservation lost")
+
+// Store keeps the records.
+type Store interface {
+	// Reserve reserves key for a request with given hash, until the request
+	// is completed or released, or lockTimeout passes. The reservation is
+	// held by token, see NewToken. If the key is already reserved or
+	// completed, it returns the existing record and false.
+	Reserve(ctx context.Context, key, token string, requestHash []byte, lockTimeout time.Duration) (*Record, bool, error)
+	// Complete stores response of the request holding the reservation for
+	// ttl. It returns ErrReservationLost if the reservation isn't held by
+	// token anymore.
+	Complete(ctx context.Context, key, token string, requestHash []byte, resp *Response, ttl time.Duration) error
+	// Release removes the reservation held by token, so the request can be
+	// retried. Reservations taken over by another request are kept.
+	Release(ctx context.Context, key, token string) error
+}
+
+// NewToken returns random token identifying a reservation.
+func NewToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// RequestHash returns hash identifying the request, retries must send the
+// same request to the same path.
+func RequestHash(path string, body []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return h.Sum(nil)
+}
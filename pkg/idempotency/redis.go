@@ -0,0 +1,122 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/golang-cz/skeleton/config"
+)
+
+const redisKeyPrefix = "idempotency:"
+
+// completeScript stores the completed record if the reservation is still
+// held by the token.
+var completeScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if not current or cjson.decode(current).token ~= ARGV[1] then
+	return 0
+end
+
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+return 1
+`)
+
+// releaseScript deletes the reservation if it's still held by the token.
+// Completed records have no token, so they're kept.
+var releaseScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if current and cjson.decode(current).token == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// redisRecord is Record stored in Redis, with token of the reservation
+// while the request is in progress.
+type redisRecord struct {
+	Record
+	Token string `json:"token,omitempty"`
+}
+
+// RedisStore keeps records in Redis, expired by Redis itself.
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(conf config.Redis) *RedisStore {
+	return &RedisStore{
+		client: redis.NewClient(&redis.Options{
+			Addr:         conf.Host,
+			DialTimeout:  time.Second,
+			ReadTimeout:  500 * time.Millisecond,
+			WriteTimeout: 500 * time.Millisecond,
+		}),
+	}
+}
+
+func (s *RedisStore) Reserve(ctx context.Context, key, token string, requestHash []byte, lockTimeout time.Duration) (*Record, bool, error) {
+	b, err := json.Marshal(&redisRecord{Record: Record{RequestHash: requestHash}, Token: token})
+	if err != nil {
+		return nil, false, fmt.Errorf("marshal record: %w", err)
+	}
+
+	// The existing record may expire between SET and GET, try again then.
+	for i := 0; i < 3; i++ {
+		ok, err := s.client.SetNX(ctx, redisKeyPrefix+key, b, lockTimeout).Result()
+		if err != nil {
+			return nil, false, fmt.Errorf("reserve key: %w", err)
+		}
+		if ok {
+			return nil, true, nil
+		}
+
+		existing, err := s.client.Get(ctx, redisKeyPrefix+key).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("get record: %w", err)
+		}
+
+		var record redisRecord
+		if err := json.Unmarshal(existing, &record); err != nil {
+			return nil, false, fmt.Errorf("unmarshal record: %w", err)
+		}
+		return &record.Record, false, nil
+	}
+
+	return nil, false, errors.New("reserve key: record keeps expiring")
+}
+
+func (s *RedisStore) Complete(ctx context.Context, key, token string, requestHash []byte, resp *Response, ttl time.Duration) error {
+	b, err := json.Marshal(&redisRecord{Record: Record{RequestHash: requestHash, Response: resp}})
+	if err != nil {
+		return fmt.Errorf("marshal record: %w", err)
+	}
+
+	stored, err := completeScript.Run(ctx, s.client, []string{redisKeyPrefix + key}, token, b, ttl.Milliseconds()).Int()
+	if err != nil {
+		return fmt.Errorf("store response: %w", err)
+	}
+	if stored == 0 {
+		return ErrReservationLost
+	}
+
+	return nil
+}
+
+func (s *RedisStore) Release(ctx context.Context, key, token string) error {
+	if err := releaseScript.Run(ctx, s.client, []string{redisKeyPrefix + key}, token).Err(); err != nil {
+		return fmt.Errorf("release key: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
@@ -0,0 +1,152 @@
+// Package jwtauth verifies JWT bearer tokens signed either by a shared
+// HS256 secret or by RS256/EdDSA keys published as JWKS.
+package jwtauth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/golang-cz/skeleton/config"
+)
+
+var ErrNotConfigured = errors.New("jwtauth: not configured")
+
+// Claims of the tokens we accept. Subject is the user id.
+type Claims struct {
+	jwt.RegisteredClaims
+	ApplicationId string `json:"app_id,omitempty"`
+}
+
+// UserId returns the subject as uuid.
+func (c *Claims) UserId() (uuid.UUID, error) {
+	return uuid.FromString(c.Subject)
+}
+
+// AppId returns the application id, uuid.Nil if the claim is missing.
+func (c *Claims) AppId() (uuid.UUID, error) {
+	if c.ApplicationId == "" {
+		return uuid.Nil, nil
+	}
+	return uuid.FromString(c.ApplicationId)
+}
+
+type Verifier struct {
+	secret []byte
+	keys   *KeySet
+	parser *jwt.Parser
+	cancel context.CancelFunc
+}
+
+// NewVerifier creates verifier from config. It returns ErrNotConfigured if
+// neither HS256 secret nor JWKS source is set.
+func NewVerifier(ctx context.Context, conf config.Auth) (*Verifier, error) {
+	v := &Verifier{}
+
+	var methods []string
+	if conf.HS256Secret != "" {
+		v.secret = []byte(conf.HS256Secret)
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+
+	if source := conf.JWKSSource(); source != "" {
+		keys, err := NewKeySet(ctx, source)
+		if err != nil {
+			return nil, err
+		}
+		v.keys = keys
+		methods = append(methods, jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg())
+
+		interval := time.Duration(conf.JWKSRefreshInterval)
+		if interval <= 0 {
+			interval = 15 * time.Minute
+		}
+
+		runCtx, cancel := context.WithCancel(context.Background())
+		v.cancel = cancel
+		go keys.Run(runCtx, interval)
+	}
+
+	if len(methods) == 0 {
+		return nil, ErrNotConfigured
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Duration(conf.Leeway)),
+	}
+	if conf.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(conf.Issuer))
+	}
+	if conf.Audience != "" {
+		opts = append(opts, jwt.WithAudience(conf.Audience))
+	}
+	v.parser = jwt.NewParser(opts...)
+
+	return v, nil
+}
+
+// Verify checks token signature and claims.
+func (v *Verifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := v.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		switch t.Method.(type) {
+		case *jwt.SigningMethodHMAC:
+			return v.secret, nil
+
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodEd25519:
+			kid, _ := t.Header["kid"].(string)
+			key, err := v.keys.Key(ctx, kid)
+			if err != nil {
+				return nil, err
+			}
+
+			// Key type must match the algorithm, eg. RSA key can't verify EdDSA token.
+			switch key.(type) {
+			case *rsa.PublicKey:
+				if _, ok := t.Method.(*jwt.SigningMethodRSA); ok {
+					return key, nil
+				}
+			case ed25519.PublicKey:
+				if _, ok := t.Method.(*jwt.SigningMethodEd25519); ok {
+					return key, nil
+				}
+			}
+			return nil, fmt.Errorf("key %q doesn't match algorithm %v", kid, t.Method.Alg())
+		}
+
+		return nil, fmt.Errorf("unexpected signing method %v", t.Method.Alg())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := claims.UserId(); err != nil {
+		return nil, fmt.Errorf("invalid subject: %w", err)
+	}
+	if _, err := claims.AppId(); err != nil {
+		return nil, fmt.Errorf("invalid app_id: %w", err)
+	}
+
+	return claims, nil
+}
+
+// Close stops JWKS refreshing.
+func (v *Verifier) Close() {
+	if v.cancel != nil {
+		v.cancel()
+	}
+}
+
+func isURL(source string) bool {
+	u, err := url.Parse(source)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https")
+}
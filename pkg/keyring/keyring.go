@@ -0,0 +1,298 @@
+package keyring
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/golang-cz/skeleton/config"
+)
+
+// Envelope layout:
+//
+//	magic | version | len(keyId) | keyId | wrapped data key | nonce | ciphertext
+//
+// The data key is generated for every value and is wrapped (encrypted) by the
+// keyring key identified by keyId. Rotating the active key therefore never
+// requires knowing anything but the keyring itself.
+var magic = []byte("\x00enc")
+
+const (
+	envelopeVersion = 1
+	keySize         = 32 // AES-256
+	nonceSize       = 12
+	tagSize         = 16
+)
+
+var ErrNotConfigured = errors.New("keyring: not configured")
+
+var DefaultKeyring *Keyring
+
+type Keyring struct {
+	activeKeyId string
+	keys        map[string][]byte
+	indexKey    []byte
+}
+
+// file represents keyring file that can be found at config.Encryption.Keyring path.
+type file struct {
+	ActiveKey string `toml:"active_key"`
+	IndexKey  string `toml:"index_key"`
+	Keys      []struct {
+		Id     string `toml:"id"`
+		Secret string `toml:"secret"`
+	} `toml:"keys"`
+}
+
+// Setup loads keyring file from config and sets it as DefaultKeyring. Keyring
+// is required, encrypted columns can't be read or written without it.
+func Setup(conf config.Encryption) error {
+	if conf.Keyring == "" {
+		return fmt.Errorf("%w: set path to keyring file in [encryption] keyring", ErrNotConfigured)
+	}
+
+	k, err := Load(conf.Keyring)
+	if err != nil {
+		return err
+	}
+
+	DefaultKeyring = k
+
+	return nil
+}
+
+func Load(path string) (*Keyring, error) {
+	var f file
+	if _, err := toml.DecodeFile(path, &f); err != nil {
+		return nil, fmt.Errorf("keyring: read %q: %w", path, err)
+	}
+
+	indexKey, err := decodeKey(f.IndexKey)
+	if err != nil {
+		return nil, fmt.Errorf("keyring: index_key: %w", err)
+	}
+
+	keys := make(map[string][]byte, len(f.Keys))
+	for _, k := range f.Keys {
+		secret, err := decodeKey(k.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("keyring: key %q: %w", k.Id, err)
+		}
+		keys[k.Id] = secret
+	}
+
+	return New(f.ActiveKey, keys, indexKey)
+}
+
+func New(activeKeyId string, keys map[string][]byte, indexKey []byte) (*Keyring, error) {
+	if _, ok := keys[activeKeyId]; !ok {
+		return nil, fmt.Errorf("keyring: active key %q not found", activeKeyId)
+	}
+
+	for id, key := range keys {
+		if id == "" || len(id) > 255 {
+			return nil, fmt.Errorf("keyring: invalid key id %q", id)
+		}
+		if len(key) != keySize {
+			return nil, fmt.Errorf("keyring: key %q must be %d bytes long", id, keySize)
+		}
+	}
+
+	if len(indexKey) < keySize {
+		return nil, fmt.Errorf("keyring: index key must be at least %d bytes long", keySize)
+	}
+
+	return &Keyring{
+		activeKeyId: activeKeyId,
+		keys:        keys,
+		indexKey:    indexKey,
+	}, nil
+}
+
+func decodeKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("decode base64: %w", err)
+	}
+	return key, nil
+}
+
+// ActiveKeyId returns id of the key used for encrypting new values.
+func (k *Keyring) ActiveKeyId() string {
+	return k.activeKeyId
+}
+
+// Encrypt seals plaintext into an envelope using the active key.
+func (k *Keyring) Encrypt(plaintext []byte) ([]byte, error) {
+	dataKey := make([]byte, keySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("keyring: generate data key: %w", err)
+	}
+
+	wrappedKey, err := seal(k.keys[k.activeKeyId], dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("keyring: wrap data key: %w", err)
+	}
+
+	ciphertext, err := seal(dataKey, plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("keyring: encrypt: %w", err)
+	}
+
+	envelope := make([]byte, 0, len(magic)+2+len(k.activeKeyId)+len(wrappedKey)+len(ciphertext))
+	envelope = append(envelope, magic...)
+	envelope = append(envelope, envelopeVersion, byte(len(k.activeKeyId)))
+	envelope = append(envelope, k.activeKeyId...)
+	envelope = append(envelope, wrappedKey...)
+	envelope = append(envelope, ciphertext...)
+
+	return envelope, nil
+}
+
+// Decrypt opens an envelope created by Encrypt with any key of the keyring.
+func (k *Keyring) Decrypt(envelope []byte) ([]byte, error) {
+	keyId, rest, err := parseEnvelope(envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok := k.keys[keyId]
+	if !ok {
+		return nil, fmt.Errorf("keyring: unknown key %q", keyId)
+	}
+
+	wrappedKeySize := nonceSize + keySize + tagSize
+	if len(rest) < wrappedKeySize {
+		return nil, errors.New("keyring: envelope too short")
+	}
+
+	dataKey, err := open(key, rest[:wrappedKeySize])
+	if err != nil {
+		return nil, fmt.Errorf("keyring: unwrap data key: %w", err)
+	}
+
+	plaintext, err := open(dataKey, rest[wrappedKeySize:])
+	if err != nil {
+		return nil, fmt.Errorf("keyring: decrypt: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+// BlindIndex returns keyed hash of given value usable for equality lookups
+// of encrypted values. The index key is never rotated, so indexes stay valid.
+func (k *Keyring) BlindIndex(value []byte) []byte {
+	mac := hmac.New(sha256.New, k.indexKey)
+	mac.Write(value)
+	return mac.Sum(nil)
+}
+
+// IsEnvelope reports whether given value was created by Encrypt.
+func IsEnvelope(value []byte) bool {
+	return bytes.HasPrefix(value, magic)
+}
+
+// KeyId returns id of the key given envelope was encrypted with.
+func KeyId(envelope []byte) (string, error) {
+	keyId, _, err := parseEnvelope(envelope)
+	return keyId, err
+}
+
+func parseEnvelope(envelope []byte) (keyId string, rest []byte, err error) {
+	if !IsEnvelope(envelope) {
+		return "", nil, errors.New("keyring: not an envelope")
+	}
+
+	header := envelope[len(magic):]
+	if len(header) < 2 {
+		return "", nil, errors.New("keyring: envelope too short")
+	}
+
+	if header[0] != envelopeVersion {
+		return "", nil, fmt.Errorf("keyring: unsupported envelope version %d", header[0])
+	}
+
+	keyIdLen := int(header[1])
+	if len(header) < 2+keyIdLen {
+		return "", nil, errors.New("keyring: envelope too short")
+	}
+
+	return string(header[2 : 2+keyIdLen]), header[2+keyIdLen:], nil
+}
+
+func seal(key, plaintext []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(key, ciphertext []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < nonceSize {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	return aead.Open(nil, ciphertext[:nonceSize], ciphertext[nonceSize:], nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("create gcm: %w", err)
+	}
+
+	return aead, nil
+}
+
+func ActiveKeyId() string {
+	if DefaultKeyring == nil {
+		return ""
+	}
+	return DefaultKeyring.ActiveKeyId()
+}
+
+func Encrypt(plaintext []byte) ([]byte, error) {
+	if DefaultKeyring == nil {
+		return nil, ErrNotConfigured
+	}
+	return DefaultKeyring.Encrypt(plaintext)
+}
+
+func Decrypt(envelope []byte) ([]byte, error) {
+	if DefaultKeyring == nil {
+		return nil, ErrNotConfigured
+	}
+	return DefaultKeyring.Decrypt(envelope)
+}
+
+func BlindIndex(value []byte) ([]byte, error) {
+	if DefaultKeyring == nil {
+		return nil, ErrNotConfigured
+	}
+	return DefaultKeyring.BlindIndex(value), nil
+}
@@ -0,0 +1,161 @@
+// Package maintenance switches all API replicas into maintenance mode at
+// once. The state is persisted, so new replicas start in it, and broadcast
+// over NATS.
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-cz/skeleton/config"
+	"github.com/golang-cz/skeleton/pkg/events"
+	"github.com/golang-cz/skeleton/pkg/nats"
+	"github.com/golang-cz/skeleton/pkg/slogger"
+	"github.com/golang-cz/skeleton/pkg/utc"
+)
+
+// State of maintenance mode.
+type State struct {
+	Enabled bool   `json:"enabled"`
+	Reason  string `json:"reason,omitempty"`
+	// Expected end of maintenance.
+	ETA       *time.Time `json:"eta,omitempty"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// RetryAfter returns time left until ETA, or fallback if there's no ETA or
+// it has passed.
+func (s State) RetryAfter(fallback time.Duration) time.Duration {
+	if s.ETA == nil {
+		return fallback
+	}
+	if left := time.Until(*s.ETA); left > 0 {
+		return left
+	}
+	return fallback
+}
+
+// Store persists the state.
+type Store interface {
+	// Get returns the last saved state, or zero state if none was saved.
+	Get(ctx context.Context) (*State, error)
+	Save(ctx context.Context, state *State) error
+}
+
+// Mode holds the state of this replica.
+type Mode struct {
+	store  Store
+	state  atomic.Pointer[State]
+	cancel context.CancelFunc
+}
+
+// New loads the state from store and keeps it in sync with other replicas.
+func New(ctx context.Context, store Store, conf config.Maintenance) (*Mode, error) {
+	m := &Mode{store: store}
+	m.state.Store(&State{})
+
+	if err := m.Refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := nats.SubscribeCoreNATS(events.EvMaintenance, func(subject string, state *State) {
+		m.apply(state, true)
+	}); err != nil {
+		err = fmt.Errorf("maintenance: %w", err)
+		slog.Error(slogger.ErrorCause(err).Error())
+	}
+
+	if interval := time.Duration(conf.SyncInterval); interval > 0 {
+		runCtx, cancel := context.WithCancel(context.Background())
+		m.cancel = cancel
+		go m.run(runCtx, interval)
+	}
+
+	return m, nil
+}
+
+// State returns the current state.
+func (m *Mode) State() State {
+	return *m.state.Load()
+}
+
+// Set saves the state and broadcasts it to all replicas.
+func (m *Mode) Set(ctx context.Context, state State) error {
+	state.UpdatedAt = utc.Now().Truncate(time.Microsecond) // DB precision.
+	if !state.Enabled {
+		state.Reason, state.ETA = "", nil
+	}
+	if state.ETA != nil {
+		eta := state.ETA.UTC()
+		state.ETA = &eta
+	}
+
+	if err := m.store.Save(ctx, &state); err != nil {
+		return fmt.Errorf("save maintenance state: %w", err)
+	}
+	m.apply(&state, false)
+
+	if err := nats.PublishCoreNATS(ctx, events.EvMaintenance, state); err != nil {
+		return fmt.Errorf("broadcast maintenance state: %w", err)
+	}
+
+	return nil
+}
+
+// Refresh reloads the state from store, which wins over broadcasts.
+func (m *Mode) Refresh(ctx context.Context) error {
+	state, err := m.store.Get(ctx)
+	if err != nil {
+		return fmt.Errorf("get maintenance state: %w", err)
+	}
+	m.apply(state, false)
+
+	return nil
+}
+
+// apply replaces the state. With onlyNewer, older state is ignored, as
+// broadcasts may come out of order.
+func (m *Mode) apply(state *State, onlyNewer bool) {
+	for {
+		current := m.state.Load()
+		if onlyNewer && state.UpdatedAt.Before(current.UpdatedAt) {
+			return
+		}
+		if m.state.CompareAndSwap(current, state) {
+			if current.Enabled != state.Enabled {
+				slog.Warn("maintenance mode changed",
+					slog.Bool("enabled", state.Enabled),
+					slog.String("reason", state.Reason),
+				)
+			}
+			return
+		}
+	}
+}
+
+func (m *Mode) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := m.Refresh(ctx); err != nil {
+			slog.Error(slogger.ErrorCause(err).Error())
+		}
+	}
+}
+
+// Close stops reloading the state.
+func (m *Mode) Close() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+}
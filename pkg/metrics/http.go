@@ -0,0 +1,154 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by route pattern, method and status code.",
+	}, []string{"route", "method", "code"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route pattern and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	httpInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_in_flight",
+		Help:      "HTTP requests being served.",
+	})
+
+	rpcRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "rpc",
+		Name:      "requests_total",
+		Help:      "RPC calls by method and HTTP status code.",
+	}, []string{"method", "code"})
+
+	rpcErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "rpc",
+		Name:      "errors_total",
+		Help:      "Failed RPC calls by method and webrpc error name.",
+	}, []string{"method", "error"})
+
+	rpcDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "rpc",
+		Name:      "request_duration_seconds",
+		Help:      "RPC call latency by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+)
+
+// unknown labels requests not matching any route or RPC method, so
+// scanners can't blow up the number of series.
+const unknown = "unknown"
+
+// HTTP records request count, latency and status codes by chi route
+// pattern. Mount it on the top-level router, the pattern is complete only
+// after the request is routed.
+func HTTP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		httpInFlight.Inc()
+		defer httpInFlight.Dec()
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		route := unknown
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		method := r.Method
+		if !knownHTTPMethod(method) {
+			method = unknown
+		}
+
+		httpRequests.WithLabelValues(route, method, strconv.Itoa(status(ww))).Inc()
+		httpDuration.WithLabelValues(route, method).Observe(time.Since(start).Seconds())
+	})
+}
+
+type rpcCtxKey struct{}
+
+// rpcCall is filled by RPCError during the call.
+type rpcCall struct {
+	errName string
+}
+
+// RPC records call count, latency and errors by webrpc method. Methods not
+// in given list are labelled "unknown".
+func RPC(methods []string) func(http.Handler) http.Handler {
+	known := make(map[string]bool, len(methods))
+	for _, m := range methods {
+		known[m] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			call := &rpcCall{}
+			r = r.WithContext(context.WithValue(r.Context(), rpcCtxKey{}, call))
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			method := path.Base(r.URL.Path)
+			if !known[method] {
+				method = unknown
+			}
+
+			rpcRequests.WithLabelValues(method, strconv.Itoa(status(ww))).Inc()
+			rpcDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+			if call.errName != "" {
+				rpcErrors.WithLabelValues(method, call.errName).Inc()
+			}
+		})
+	}
+}
+
+// RPCError marks the RPC call in ctx as failed with given webrpc error name.
+// Call it from the webrpc OnError hook.
+func RPCError(ctx context.Context, errName string) {
+	if call, ok := ctx.Value(rpcCtxKey{}).(*rpcCall); ok {
+		call.errName = errName
+	}
+}
+
+// status returns response status, handlers not writing any respond with 200.
+func status(ww middleware.WrapResponseWriter) int {
+	if ww.Status() == 0 {
+		return http.StatusOK
+	}
+	return ww.Status()
+}
+
+func knownHTTPMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	jobRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "scheduler",
+		Name:      "job_runs_total",
+		Help:      "Finished scheduler job runs by runner, job and result.",
+	}, []string{"runner", "job", "result"})
+
+	jobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "scheduler",
+		Name:      "job_duration_seconds",
+		Help:      "Scheduler job run duration by runner and job.",
+		Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300, 900},
+	}, []string{"runner", "job"})
+
+	jobsRunning = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "scheduler",
+		Name:      "jobs_running",
+		Help:      "Scheduler jobs running by runner and job.",
+	}, []string{"runner", "job"})
+
+	// Start of the current run by runner and job, jobs run one at a time.
+	jobStarts sync.Map
+)
+
+type jobKey struct {
+	runner string
+	job    string
+}
+
+// JobStarted records start of a job run.
+func JobStarted(runner, job string) {
+	jobStarts.Store(jobKey{runner, job}, time.Now())
+	jobsRunning.WithLabelValues(runner, job).Inc()
+}
+
+// JobFinished records end of a job run started by JobStarted, err is the
+// error returned by the job, if any.
+func JobFinished(runner, job string, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	jobRuns.WithLabelValues(runner, job, result).Inc()
+
+	start, ok := jobStarts.LoadAndDelete(jobKey{runner, job})
+	if !ok {
+		return
+	}
+	jobsRunning.WithLabelValues(runner, job).Dec()
+	jobDuration.WithLabelValues(runner, job).Observe(time.Since(start.(time.Time)).Seconds())
+}
@@ -0,0 +1,47 @@
+// Package metrics exposes Prometheus metrics of the API and the scheduler.
+//
+// Keep labels low-cardinality: route patterns, RPC method and error names,
+// job names and status codes are fine, raw paths, ids or any other user input
+// never are.
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "skeleton"
+
+// Handler serves all registered metrics, including Go runtime and process
+// metrics, in Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// RegisterDB registers connection pool stats of given database, labelled
+// with dbName.
+func RegisterDB(dbName string, db *sql.DB) error {
+	return register(collectors.NewDBStatsCollector(db, dbName))
+}
+
+// register registers collector to the default registry. Collectors
+// registered already are kept.
+func register(c prometheus.Collector) error {
+	err := prometheus.Register(c)
+
+	var are prometheus.AlreadyRegisteredError
+	if errors.As(err, &are) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("register metrics collector: %w", err)
+	}
+
+	return nil
+}
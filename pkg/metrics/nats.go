@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"github.com/nats-io/nats.go"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RegisterNATS registers stats of the NATS connection returned by conn.
+// The connection may change or be nil, eg. before NATS is connected.
+func RegisterNATS(conn func() *nats.Conn) error {
+	return register(&natsCollector{conn: conn})
+}
+
+var (
+	natsConnected = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "nats", "connected"),
+		"Whether the NATS client is connected.", nil, nil,
+	)
+	natsInMsgs = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "nats", "in_msgs_total"),
+		"Messages received by the NATS client.", nil, nil,
+	)
+	natsOutMsgs = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "nats", "out_msgs_total"),
+		"Messages sent by the NATS client.", nil, nil,
+	)
+	natsInBytes = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "nats", "in_bytes_total"),
+		"Bytes received by the NATS client.", nil, nil,
+	)
+	natsOutBytes = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "nats", "out_bytes_total"),
+		"Bytes sent by the NATS client.", nil, nil,
+	)
+	natsReconnects = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "nats", "reconnects_total"),
+		"Reconnects of the NATS client.", nil, nil,
+	)
+)
+
+type natsCollector struct {
+	conn func() *nats.Conn
+}
+
+func (c *natsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- natsConnected
+	ch <- natsInMsgs
+	ch <- natsOutMsgs
+	ch <- natsInBytes
+	ch <- natsOutBytes
+	ch <- natsReconnects
+}
+
+func (c *natsCollector) Collect(ch chan<- prometheus.Metric) {
+	conn := c.conn()
+
+	var connected float64
+	var stats nats.Statistics
+	if conn != nil {
+		if conn.IsConnected() {
+			connected = 1
+		}
+		stats = conn.Stats()
+	}
+
+	ch <- prometheus.MustNewConstMetric(natsConnected, prometheus.GaugeValue, connected)
+	ch <- prometheus.MustNewConstMetric(natsInMsgs, prometheus.CounterValue, float64(stats.InMsgs))
+	ch <- prometheus.MustNewConstMetric(natsOutMsgs, prometheus.CounterValue, float64(stats.OutMsgs))
+	ch <- prometheus.MustNewConstMetric(natsInBytes, prometheus.CounterValue, float64(stats.InBytes))
+	ch <- prometheus.MustNewConstMetric(natsOutBytes, prometheus.CounterValue, float64(stats.OutBytes))
+	ch <- prometheus.MustNewConstMetric(natsReconnects, prometheus.CounterValue, float64(stats.Reconnects))
+}
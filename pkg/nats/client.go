@@ -1,17 +1,26 @@
 package nats
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/url"
 	"reflect"
+	"strings"
 	"time"
 
 	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/golang-cz/skeleton/config"
+	"github.com/golang-cz/skeleton/internal/reqctx"
+	"github.com/golang-cz/skeleton/pkg/tracing"
 )
 
 type Client struct {
@@ -83,7 +92,7 @@ func (c *Client) Ping() error {
 }
 
 func (c *Client) Stats() nats.Statistics {
-	if c.NATSConn == nil {
+	if c.NATSConn != nil {
 		return c.NATSConn.Stats()
 	}
 	return nats.Statistics{}
@@ -104,26 +113,44 @@ func (c *Client) Close() {
 	c.NATSConn.Close()
 }
 
-func (c *Client) Publish(subject string, v interface{}) error {
+func (c *Client) Publish(ctx context.Context, subject string, v interface{}) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, spanName(subject, "publish"),
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(messagingAttrs(subject, semconv.MessagingOperationPublish)...),
+	)
+	defer func() {
+		if err != nil {
+			tracing.RecordError(ctx, err)
+		}
+		span.End()
+	}()
+
 	// Log alert if message is trying to be published when NATS client is disconnected
 	if !c.NATSConn.IsConnected() {
 		slog.Error(fmt.Sprintf("Trying to publish message to subject (%s) but NATS client is disconnected - payload: %+v", subject, v))
 	}
 
-	var err error
+	msg := nats.NewMsg(subject)
 	switch data := v.(type) {
 	case []byte:
-		err = c.NATSConn.Publish(subject, data)
+		msg.Data = data
 	default:
-		var b []byte
-		b, err = json.Marshal(v)
+		b, err := json.Marshal(v)
 		if err != nil {
 			return fmt.Errorf("marshal: %w", err)
 		}
-		err = c.NATSConn.Publish(subject, b)
+		msg.Data = b
 	}
 
-	if err != nil {
+	// Pass request id and trace context to subscribers, see processMsg.
+	if c.NATSConn.HeadersSupported() {
+		if requestId := reqctx.GetRequestId(ctx); requestId != "" {
+			msg.Header.Set(reqctx.RequestIdHeader, requestId)
+		}
+		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(msg.Header))
+	}
+
+	if err := c.NATSConn.PublishMsg(msg); err != nil {
 		return fmt.Errorf("publishing message to %s : %w", subject, err)
 	}
 
@@ -133,13 +160,13 @@ func (c *Client) Publish(subject string, v interface{}) error {
 func (c *Client) Subscribe(subj string, cb interface{}) error {
 	// check if callback is valid, expects to be a function with two arguments
 	// eg; func PostPublished(subject string, post *presenter.Post)
-	argType, _, err := argInfo(cb)
+	argType, numArgs, err := argInfo(cb)
 	if err != nil {
 		return fmt.Errorf("invalid argument type for callback: %w", err)
 	}
 
 	_, err = c.NATSConn.Subscribe(subj, func(msg *nats.Msg) {
-		processMsg(msg.Subject, msg.Data, argType, cb)
+		processMsg(msg, argType, numArgs, cb)
 	})
 	if err != nil {
 		return fmt.Errorf("subscribe to subject %q: %w", subj, err)
@@ -148,8 +175,19 @@ func (c *Client) Subscribe(subj string, cb interface{}) error {
 	return nil
 }
 
-// Process NATS published message and unmarshal data into callback argument
-func processMsg(msgSubject string, msgData []byte, argType reflect.Type, cb interface{}) {
+// Process NATS published message and unmarshal data into callback argument.
+// Callbacks with context get request id of the publisher and span continuing
+// its trace in it.
+func processMsg(msg *nats.Msg, argType reflect.Type, numArgs int, cb interface{}) {
+	msgSubject, msgData := msg.Subject, msg.Data
+
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.HeaderCarrier(msg.Header))
+	ctx, span := tracing.Tracer().Start(ctx, spanName(msgSubject, "process"),
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(messagingAttrs(msgSubject, semconv.MessagingOperationProcess)...),
+	)
+	defer span.End()
+
 	var oPtr reflect.Value
 	if argType.Kind() != reflect.Ptr {
 		oPtr = reflect.New(argType)
@@ -160,13 +198,39 @@ func processMsg(msgSubject string, msgData []byte, argType reflect.Type, cb inte
 	if len(msgData) > 0 {
 		err := json.Unmarshal(msgData, oPtr.Interface())
 		if err != nil {
+			tracing.RecordError(ctx, fmt.Errorf("unmarshal message: %w", err))
 			return
 		}
 	}
 	if argType.Kind() != reflect.Ptr {
 		oPtr = reflect.Indirect(oPtr)
 	}
-	reflect.ValueOf(cb).Call([]reflect.Value{reflect.ValueOf(msgSubject), oPtr})
+
+	args := []reflect.Value{reflect.ValueOf(msgSubject), oPtr}
+	if numArgs == 3 {
+		if requestId := msg.Header.Get(reqctx.RequestIdHeader); reqctx.ValidRequestId(requestId) {
+			ctx = reqctx.SetRequestId(ctx, requestId)
+		}
+		args = append([]reflect.Value{reflect.ValueOf(ctx)}, args...)
+	}
+	reflect.ValueOf(cb).Call(args)
+}
+
+// spanName names span by subject, except reply inboxes which are unique.
+func spanName(subject, operation string) string {
+	if strings.HasPrefix(subject, nats.InboxPrefix) {
+		return "(temporary) " + operation
+	}
+	return subject + " " + operation
+}
+
+func messagingAttrs(subject string, operation attribute.KeyValue) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		semconv.MessagingSystem("nats"),
+		semconv.MessagingDestinationName(subject),
+		semconv.MessagingDestinationTemporary(strings.HasPrefix(subject, nats.InboxPrefix)),
+		operation,
+	}
 }
 
 // Reads callback function and return total number of arguments and their types
@@ -176,8 +240,14 @@ func argInfo(cb interface{}) (reflect.Type, int, error) {
 		return nil, 0, fmt.Errorf("callback handler needs to be a function")
 	}
 	numArgs := cbType.NumIn()
-	if numArgs != 2 {
-		return nil, numArgs, fmt.Errorf("callback handler needs to have 2 arguments")
+	switch numArgs {
+	case 2:
+	case 3:
+		if cbType.In(0) != reflect.TypeOf((*context.Context)(nil)).Elem() {
+			return nil, numArgs, fmt.Errorf("first argument of callback handler with 3 arguments needs to be context.Context")
+		}
+	default:
+		return nil, numArgs, fmt.Errorf("callback handler needs to have 2 or 3 arguments")
 	}
 	return cbType.In(numArgs - 1), numArgs, nil
 }
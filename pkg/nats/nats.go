@@ -1,6 +1,7 @@
 package nats
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/nats-io/nats.go"
@@ -17,11 +18,14 @@ type NATSClient interface {
 	Unsubscribe()
 	Close()
 
-	// Publish a messages to NATS
-	Publish(subject string, payload interface{}) error
+	// Publish a messages to NATS, with request id from ctx in headers.
+	Publish(ctx context.Context, subject string, payload interface{}) error
 
-	// Subscribes to a NATS subject
-	Subscribe(subject string, payload interface{}) error
+	// Subscribes to a NATS subject. Callback is either
+	// func(subject string, payload T) or
+	// func(ctx context.Context, subject string, payload T), the ctx
+	// carries request id of the publisher.
+	Subscribe(subject string, cb interface{}) error
 }
 
 func Connect(service string, conf config.NATS) (*Client, error) {
@@ -63,8 +67,8 @@ func SubscribeCoreNATS(subj string, cb interface{}) error {
 	return nil
 }
 
-func PublishCoreNATS(subj string, v interface{}) error {
-	err := DefaultClient.Publish(subj, v)
+func PublishCoreNATS(ctx context.Context, subj string, v interface{}) error {
+	err := DefaultClient.Publish(ctx, subj, v)
 	if err != nil {
 		return fmt.Errorf("publish message: %w", err)
 	}
@@ -1,6 +1,7 @@
 package nats
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -22,13 +23,13 @@ func (c *nopClient) Unsubscribe() {}
 
 func (c *nopClient) Close() {}
 
-func (c *nopClient) Publish(subject string, v interface{}) error {
+func (c *nopClient) Publish(ctx context.Context, subject string, v interface{}) error {
 	err := fmt.Errorf("trying to publish message to subject (%s) but NATS client is disconnected - payload: %+v", subject, v)
 	if c.Alert {
 		slog.Error(err.Error())
 	} else {
 		// Just log a warning to indicate that some functionality depends on NATS but the client is not connected when running in development mode
-		slog.Warn("%v", err)
+		slog.Warn(err.Error())
 	}
 	return nil
 }
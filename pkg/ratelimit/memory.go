@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// cleanupInterval of full buckets, which are the same as missing ones.
+const cleanupInterval = time.Minute
+
+// MemoryStore keeps buckets in memory, so the limits apply to a single
+// instance only.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	cancel  context.CancelFunc
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time // When the bucket refills completely.
+}
+
+func NewMemoryStore() *MemoryStore {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &MemoryStore{
+		buckets: map[string]*bucket{},
+		cancel:  cancel,
+	}
+	go s.cleanup(ctx)
+
+	return s
+}
+
+func (s *MemoryStore) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.burst()), updated: now}
+		s.buckets[key] = b
+	}
+
+	tokens, allowed := limit.take(b.tokens, now.Sub(b.updated))
+	b.tokens = tokens
+	b.updated = now
+
+	res := limit.result(tokens, allowed)
+	b.full = now.Add(res.Reset)
+
+	return res, nil
+}
+
+func (s *MemoryStore) cleanup(ctx context.Context) {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.mu.Lock()
+			for key, b := range s.buckets {
+				if now.After(b.full) {
+					delete(s.buckets, key)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *MemoryStore) Close() error {
+	s.cancel()
+	return nil
+}
@@ -0,0 +1,211 @@
+// Package ratelimit implements token bucket rate limiting with buckets kept
+// either in memory or in Redis.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-cz/skeleton/config"
+)
+
+// Parts of the bucket key.
+const (
+	KeyIP     = "ip"
+	KeyUser   = "user"
+	KeyApiKey = "api_key"
+	KeyMethod = "method"
+)
+
+var keyParts = []string{KeyIP, KeyUser, KeyApiKey, KeyMethod}
+
+// Limit of a token bucket. The bucket holds up to Burst tokens and is
+// refilled by Requests tokens per Period. Every request takes one token.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+// rate returns number of tokens added per second.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+func (l Limit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+// take refills the bucket holding given tokens after elapsed time and takes
+// one token if possible. It returns the tokens left.
+func (l Limit) take(tokens float64, elapsed time.Duration) (float64, bool) {
+	tokens = math.Min(float64(l.burst()), tokens+elapsed.Seconds()*l.rate())
+	if tokens < 1 {
+		return tokens, false
+	}
+	return tokens - 1, true
+}
+
+func (l Limit) result(tokens float64, allowed bool) Result {
+	res := Result{
+		Allowed:   allowed,
+		Limit:     l.burst(),
+		Period:    l.Period,
+		Remaining: int(tokens),
+		Reset:     l.seconds(float64(l.burst()) - tokens),
+	}
+	if !allowed {
+		res.RetryAfter = l.seconds(1 - tokens)
+	}
+	return res
+}
+
+// seconds returns time needed to refill given tokens.
+func (l Limit) seconds(tokens float64) time.Duration {
+	return time.Duration(tokens / l.rate() * float64(time.Second))
+}
+
+// Result of taking a token from a bucket.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Period    time.Duration
+	Remaining int
+	// Time until the bucket is full again.
+	Reset time.Duration
+	// Time until the next request is allowed, zero if this one was.
+	RetryAfter time.Duration
+}
+
+// Store keeps the token buckets.
+type Store interface {
+	// Allow takes one token from the bucket under key.
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+	Close() error
+}
+
+// Rule limits requests to matching paths.
+type Rule struct {
+	Name  string
+	Path  string
+	Key   []string
+	Limit Limit
+}
+
+// Matches reports whether the rule applies to given request path.
+func (r Rule) Matches(path string) bool {
+	if prefix, ok := strings.CutSuffix(r.Path, "*"); ok {
+		return strings.HasPrefix(path, prefix)
+	}
+	return path == r.Path
+}
+
+// Authenticated reports whether the rule is keyed by the caller, so it can
+// be checked only once the request is authenticated.
+func (r Rule) Authenticated() bool {
+	return slices.Contains(r.Key, KeyUser) || slices.Contains(r.Key, KeyApiKey)
+}
+
+// Limiter checks requests against rules.
+type Limiter struct {
+	store Store
+	rules []Rule
+}
+
+// New creates limiter with the configured backend.
+func New(conf config.RateLimit, redis config.Redis) (*Limiter, error) {
+	rules := make([]Rule, 0, len(conf.Rules))
+	names := map[string]bool{}
+	for _, rc := range conf.Rules {
+		rule := Rule{
+			Name: rc.Name,
+			Path: rc.Path,
+			Key:  rc.Key,
+			Limit: Limit{
+				Requests: rc.Requests,
+				Period:   time.Duration(rc.Period),
+				Burst:    rc.Burst,
+			},
+		}
+
+		switch {
+		case rule.Name == "":
+			return nil, errors.New("rate limit rule without name")
+		case names[rule.Name]:
+			return nil, fmt.Errorf("duplicate rate limit rule %q", rule.Name)
+		case rule.Path == "":
+			return nil, fmt.Errorf("rate limit rule %q: path is required", rule.Name)
+		case rule.Limit.Requests <= 0 || rule.Limit.Period <= 0:
+			return nil, fmt.Errorf("rate limit rule %q: requests and period must be positive", rule.Name)
+		case len(rule.Key) == 0:
+			return nil, fmt.Errorf("rate limit rule %q: key is required", rule.Name)
+		}
+		for _, part := range rule.Key {
+			if !slices.Contains(keyParts, part) {
+				return nil, fmt.Errorf("rate limit rule %q: unknown key %q, expected one of %v", rule.Name, part, keyParts)
+			}
+		}
+
+		names[rule.Name] = true
+		rules = append(rules, rule)
+	}
+
+	var store Store
+	switch conf.Backend {
+	case "", "memory":
+		store = NewMemoryStore()
+	case "redis":
+		store = NewRedisStore(redis)
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend %q", conf.Backend)
+	}
+
+	return &Limiter{store: store, rules: rules}, nil
+}
+
+// Allow takes a token from buckets of all rules matching path, either the
+// authenticated ones or the rest, see Rule.Authenticated. The key function
+// returns value of given key part for the request. The returned result is
+// the one closest to the limit, or of the longest wait if the request was
+// refused. ok is false if no rule matched.
+func (l *Limiter) Allow(ctx context.Context, path string, authenticated bool, key func(part string) string) (res Result, ok bool, err error) {
+	for _, rule := range l.rules {
+		if !rule.Matches(path) || rule.Authenticated() != authenticated {
+			continue
+		}
+
+		parts := make([]string, len(rule.Key))
+		for i, part := range rule.Key {
+			parts[i] = key(part)
+		}
+
+		r, err := l.store.Allow(ctx, "ratelimit:"+rule.Name+":"+strings.Join(parts, ":"), rule.Limit)
+		if err != nil {
+			return Result{}, false, fmt.Errorf("rule %q: %w", rule.Name, err)
+		}
+
+		switch {
+		case !ok:
+			res = r
+		case !r.Allowed && (res.Allowed || r.RetryAfter > res.RetryAfter):
+			res = r
+		case r.Allowed && res.Allowed && r.Remaining < res.Remaining:
+			res = r
+		}
+		ok = true
+	}
+
+	return res, ok, nil
+}
+
+func (l *Limiter) Close() error {
+	return l.store.Close()
+}
@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/golang-cz/skeleton/config"
+)
+
+// takeScript is Limit.take done atomically in Redis, using Redis clock so
+// instances with skewed clocks share the buckets correctly. Buckets expire
+// once they're full.
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) + tonumber(time[2]) / 1000000
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(state[1]) or burst
+local updated = tonumber(state[2]) or now
+
+tokens = math.min(burst, tokens + math.max(0, now - updated) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1000)
+
+return {allowed, tostring(tokens)}
+`)
+
+// RedisStore keeps buckets in Redis, so the limits are shared by all
+// instances.
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(conf config.Redis) *RedisStore {
+	return &RedisStore{
+		client: redis.NewClient(&redis.Options{
+			Addr:         conf.Host,
+			DialTimeout:  time.Second,
+			ReadTimeout:  500 * time.Millisecond,
+			WriteTimeout: 500 * time.Millisecond,
+		}),
+	}
+}
+
+func (s *RedisStore) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	out, err := takeScript.Run(ctx, s.client, []string{key}, limit.rate(), limit.burst()).Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("run take script: %w", err)
+	}
+	if len(out) != 2 {
+		return Result{}, fmt.Errorf("unexpected take script result: %v", out)
+	}
+
+	allowed, _ := out[0].(int64)
+	tokensStr, _ := out[1].(string)
+	tokens, err := strconv.ParseFloat(tokensStr, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("parse tokens: %w", err)
+	}
+
+	return limit.result(tokens, allowed == 1), nil
+}
+
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
@@ -0,0 +1,167 @@
+// Package rbac checks permissions users have by their roles in an
+// application. Grants are cached per replica and invalidated on all replicas
+// over NATS when roles of users change.
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+
+	"github.com/golang-cz/skeleton/config"
+	"github.com/golang-cz/skeleton/pkg/events"
+	"github.com/golang-cz/skeleton/pkg/nats"
+	"github.com/golang-cz/skeleton/pkg/slogger"
+)
+
+const (
+	defaultCacheTTL  = time.Minute
+	defaultCacheSize = 10000
+)
+
+// Grants are roles of a user in an application and permissions the roles
+// give, both sorted.
+type Grants struct {
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
+// Has reports whether the permission is granted.
+func (g *Grants) Has(permission string) bool {
+	_, found := slices.BinarySearch(g.Permissions, permission)
+	return found
+}
+
+// Store loads grants of users.
+type Store interface {
+	Grants(ctx context.Context, userId, applicationId uuid.UUID) (*Grants, error)
+}
+
+// invalidation is broadcast when grants change. Nil UserId invalidates
+// grants of all users, eg. after permissions of a role changed.
+type invalidation struct {
+	UserId uuid.UUID `json:"userId"`
+}
+
+type cacheKey struct {
+	userId        uuid.UUID
+	applicationId uuid.UUID
+}
+
+type cacheEntry struct {
+	grants  *Grants
+	expires time.Time
+}
+
+// Authorizer caches grants loaded from store.
+type Authorizer struct {
+	store Store
+	ttl   time.Duration
+	size  int
+
+	mu    sync.Mutex
+	cache map[cacheKey]cacheEntry
+	// Incremented on invalidation, so grants loaded meanwhile aren't cached.
+	generation uint64
+}
+
+// New creates Authorizer and subscribes to invalidations of other replicas.
+func New(store Store, conf config.RBAC) *Authorizer {
+	a := &Authorizer{
+		store: store,
+		ttl:   time.Duration(conf.CacheTTL),
+		size:  conf.CacheSize,
+		cache: map[cacheKey]cacheEntry{},
+	}
+	if a.ttl <= 0 {
+		a.ttl = defaultCacheTTL
+	}
+	if a.size <= 0 {
+		a.size = defaultCacheSize
+	}
+
+	if err := nats.SubscribeCoreNATS(events.EvPermissions, func(subject string, inv *invalidation) {
+		a.invalidate(inv.UserId)
+	}); err != nil {
+		err = fmt.Errorf("rbac: %w", err)
+		slog.Error(slogger.ErrorCause(err).Error())
+	}
+
+	return a
+}
+
+// Grants returns grants of the user in the application.
+func (a *Authorizer) Grants(ctx context.Context, userId, applicationId uuid.UUID) (*Grants, error) {
+	key := cacheKey{userId: userId, applicationId: applicationId}
+
+	a.mu.Lock()
+	entry, ok := a.cache[key]
+	generation := a.generation
+	a.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.grants, nil
+	}
+
+	grants, err := a.store.Grants(ctx, userId, applicationId)
+	if err != nil {
+		return nil, fmt.Errorf("load grants of user %v: %w", userId, err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.generation == generation {
+		if len(a.cache) >= a.size {
+			a.evict()
+		}
+		a.cache[key] = cacheEntry{grants: grants, expires: time.Now().Add(a.ttl)}
+	}
+
+	return grants, nil
+}
+
+// Invalidate drops cached grants of the user on all replicas. Nil userId
+// drops grants of all users.
+func (a *Authorizer) Invalidate(ctx context.Context, userId uuid.UUID) error {
+	a.invalidate(userId)
+
+	if err := nats.PublishCoreNATS(ctx, events.EvPermissions, invalidation{UserId: userId}); err != nil {
+		return fmt.Errorf("broadcast invalidation of permissions: %w", err)
+	}
+
+	return nil
+}
+
+func (a *Authorizer) invalidate(userId uuid.UUID) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.generation++
+	if userId.IsNil() {
+		clear(a.cache)
+		return
+	}
+	for key := range a.cache {
+		if key.userId == userId {
+			delete(a.cache, key)
+		}
+	}
+}
+
+// evict drops expired entries, or all of them if none has expired. Callers
+// must hold the lock.
+func (a *Authorizer) evict() {
+	now := time.Now()
+	for key, entry := range a.cache {
+		if now.After(entry.expires) {
+			delete(a.cache, key)
+		}
+	}
+	if len(a.cache) >= a.size {
+		clear(a.cache)
+	}
+}
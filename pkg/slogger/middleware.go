@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/golang-cz/skeleton/config"
 	"github.com/golang-cz/skeleton/internal/reqctx"
@@ -59,6 +60,8 @@ func SloggerMiddleware(conf *config.Config) func(next http.Handler) http.Handler
 				statusCode := ww.Status()
 				timeTaken := time.Since(requestStart)
 				requestBodyLength := int(r.ContentLength)
+				// Bytes sent, ie. compressed. Compression middleware adds
+				// uncompressed count to attrStorage.
 				responseBodyLength := ww.BytesWritten()
 
 				logLevel := statusLevel(statusCode)
@@ -101,7 +104,11 @@ func SloggerMiddleware(conf *config.Config) func(next http.Handler) http.Handler
 				}
 
 				if conf.Debug.HttpResponseBody {
-					attrs = append(attrs, slog.String("responseBody", respBody.String()))
+					body := respBody.String()
+					if encoding := ww.Header().Get("Content-Encoding"); encoding != "" {
+						body = fmt.Sprintf("[%s encoded, %d bytes]", encoding, respBody.Len())
+					}
+					attrs = append(attrs, slog.String("responseBody", body))
 				}
 
 				slog.LogAttrs(ctx, logLevel, msg, attrs...)
@@ -181,12 +188,21 @@ func statusLevel(status int) slog.Level {
 // Custom slog handler for extracting values from context
 func (h *ProductionHandler) Handle(ctx context.Context, r slog.Record) error {
 	// Log reqctx values.
+	if requestId := reqctx.GetRequestId(ctx); requestId != "" {
+		r.AddAttrs(slog.String("requestId", requestId))
+	}
 	if userId := reqctx.GetUserId(ctx); !userId.IsNil() {
 		r.AddAttrs(slog.Any("userId", userId))
 	}
 	if applicationId := reqctx.GetApplicationId(ctx); !applicationId.IsNil() {
 		r.AddAttrs(slog.Any("applicationId", applicationId))
 	}
+	if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.IsValid() {
+		r.AddAttrs(
+			slog.String("traceId", spanCtx.TraceID().String()),
+			slog.String("spanId", spanCtx.SpanID().String()),
+		)
+	}
 
 	err := h.Handler.Handle(ctx, r)
 	if err != nil {
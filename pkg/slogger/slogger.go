@@ -14,14 +14,17 @@ type ProductionHandler struct {
 	slog.Handler
 }
 
+// level of the default logger, it can be changed at runtime by SetLevel.
+var level = new(slog.LevelVar)
+
 func New(appName string, version string, production bool) (*slog.Logger, error) {
 	if appName == "" {
 		return nil, errors.New("appName is not defined")
 	}
 
-	level := slog.LevelDebug
+	level.Set(slog.LevelDebug)
 	if production {
-		level = slog.LevelInfo
+		level.Set(slog.LevelInfo)
 	}
 
 	handlerOptions := &slog.HandlerOptions{
@@ -106,6 +109,29 @@ func getReplaceAttr(production bool) func(groups []string, a slog.Attr) slog.Att
 	}
 }
 
+// Level returns current level of the default logger.
+func Level() slog.Level {
+	return level.Level()
+}
+
+// SetLevel changes level of the default logger.
+func SetLevel(l slog.Level) {
+	level.Set(l)
+}
+
+// ParseLevel parses level name, eg. "info" or "debug-2", "trace" included.
+func ParseLevel(s string) (slog.Level, error) {
+	if strings.EqualFold(s, "trace") {
+		return LevelTrace, nil
+	}
+
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return 0, err
+	}
+	return l, nil
+}
+
 // errorCause recursively unwraps given error and returns the topmost
 // non-nil error cause, same as github.com/pkg/errors.Cause(err).
 func ErrorCause(err error) (cause error) {
@@ -0,0 +1,156 @@
+package static
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Live reload endpoints, relative to the mount path.
+const (
+	reloadScript = "__livereload.js"
+	reloadEvents = "__livereload"
+
+	reloadPollInterval = 500 * time.Millisecond
+)
+
+// reloader polls the files and tells browsers to reload on changes, over
+// server-sent events. Polling needs no file watching support from the OS.
+type reloader struct {
+	mu      sync.Mutex
+	clients map[chan struct{}]struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+func newReloader(fsys fs.FS) *reloader {
+	ctx, cancel := context.WithCancel(context.Background())
+	rl := &reloader{
+		clients: map[chan struct{}]struct{}{},
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	go rl.watch(ctx, fsys)
+
+	return rl
+}
+
+func (rl *reloader) watch(ctx context.Context, fsys fs.FS) {
+	ticker := time.NewTicker(reloadPollInterval)
+	defer ticker.Stop()
+
+	last := fingerprint(fsys)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if current := fingerprint(fsys); current != last {
+			last = current
+			slog.Debug("static files changed, reloading browsers")
+			rl.notify()
+		}
+	}
+}
+
+// fingerprint changes whenever a file is added, removed or modified.
+func fingerprint(fsys fs.FS) string {
+	var count, size, modTime int64
+	_ = fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		count++
+		size += info.Size()
+		modTime ^= info.ModTime().UnixNano()
+		return nil
+	})
+	return fmt.Sprintf("%d-%d-%d", count, size, modTime)
+}
+
+func (rl *reloader) notify() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	for client := range rl.clients {
+		select {
+		case client <- struct{}{}:
+		default: // Reload is pending already.
+		}
+	}
+}
+
+func (rl *reloader) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	client := make(chan struct{}, 1)
+	rl.mu.Lock()
+	rl.clients[client] = struct{}{}
+	rl.mu.Unlock()
+
+	defer func() {
+		rl.mu.Lock()
+		delete(rl.clients, client)
+		rl.mu.Unlock()
+	}()
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-rl.ctx.Done():
+			return
+		case <-client:
+			fmt.Fprint(w, "event: reload\ndata: {}\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+func (rl *reloader) serveScript(w http.ResponseWriter, r *http.Request, prefix string) {
+	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	fmt.Fprintf(w, "new EventSource(%q).addEventListener(\"reload\", () => location.reload());\n", prefix+"/"+reloadEvents)
+}
+
+// inject adds the live reload script to the HTML file.
+func (rl *reloader) inject(fsys fs.FS, name, prefix string) (io.ReadSeeker, error) {
+	b, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	script := []byte(fmt.Sprintf(`<script src="%s/%s"></script>`, prefix, reloadScript))
+	if i := bytes.LastIndex(b, []byte("</body>")); i >= 0 {
+		b = append(b[:i:i], append(script, b[i:]...)...)
+	} else {
+		b = append(b, script...)
+	}
+
+	return bytes.NewReader(b), nil
+}
+
+func (rl *reloader) close() {
+	rl.cancel()
+}
@@ -0,0 +1,269 @@
+// Package static serves a single page application from a file system,
+// usually the one embedded by the web package.
+package static
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-cz/skeleton/config"
+	"github.com/golang-cz/skeleton/pkg/compress"
+)
+
+const (
+	indexFile = "index.html"
+
+	cacheImmutable  = "public, max-age=31536000, immutable"
+	cacheRevalidate = "no-cache"
+)
+
+// precompressed are extensions of precompressed variants of files, by
+// encoding in order of preference.
+var (
+	precompressedEncodings = []string{"br", "zstd", "gzip"}
+	precompressed          = map[string]string{"br": ".br", "zstd": ".zst", "gzip": ".gz"}
+)
+
+// Server serves files. Paths not matching a file nor a directory with
+// index.html fall back to index.html, unless they look like a file, ie.
+// have an extension.
+type Server struct {
+	fsys   fs.FS
+	conf   config.Static
+	prefix string // Mount path without trailing slash.
+
+	etags  sync.Map  // Of embedded files, by name.
+	reload *reloader // Nil unless in dev mode.
+}
+
+// New creates server of the files in fsys, or in conf.Dir in dev mode.
+func New(fsys fs.FS, conf config.Static) (*Server, error) {
+	s := &Server{
+		fsys:   fsys,
+		conf:   conf,
+		prefix: strings.TrimSuffix(MountPath(conf), "/"),
+	}
+
+	if conf.Dev {
+		if _, err := os.Stat(conf.Dir); err != nil {
+			return nil, fmt.Errorf("dev dir: %w", err)
+		}
+		s.fsys = os.DirFS(conf.Dir)
+		s.reload = newReloader(s.fsys)
+	}
+
+	if _, err := fs.Stat(s.fsys, indexFile); err != nil {
+		return nil, fmt.Errorf("%s: %w", indexFile, err)
+	}
+
+	return s, nil
+}
+
+// MountPath returns the configured mount path with leading and trailing
+// slash.
+func MountPath(conf config.Static) string {
+	mountPath := strings.Trim(conf.MountPath, "/")
+	if mountPath == "" {
+		return "/"
+	}
+	return "/" + mountPath + "/"
+}
+
+// Match reports whether the path is served by the server. Paths starting
+// with "/_" are reserved for the API.
+func (s *Server) Match(urlPath string) bool {
+	if strings.HasPrefix(urlPath, "/_") {
+		return false
+	}
+	return urlPath == s.prefix || strings.HasPrefix(urlPath, s.prefix+"/")
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(path.Clean(strings.TrimPrefix(r.URL.Path, s.prefix)), "/")
+
+	if s.reload != nil {
+		switch name {
+		case reloadScript:
+			s.reload.serveScript(w, r, s.prefix)
+			return
+		case reloadEvents:
+			s.reload.serveEvents(w, r)
+			return
+		}
+	}
+
+	if !s.Match(r.URL.Path) {
+		http.NotFound(w, r)
+		return
+	}
+	if r.URL.Path == s.prefix {
+		http.Redirect(w, r, s.prefix+"/", http.StatusMovedPermanently)
+		return
+	}
+
+	name, ok := s.resolve(name)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.serveFile(w, r, name)
+}
+
+// resolve returns name of the file to serve for the path.
+func (s *Server) resolve(name string) (string, bool) {
+	if name == "" || name == "." {
+		return indexFile, true
+	}
+
+	info, err := fs.Stat(s.fsys, name)
+	if err == nil && !info.IsDir() {
+		return name, true
+	}
+	if err == nil && info.IsDir() {
+		index := path.Join(name, indexFile)
+		if _, err := fs.Stat(s.fsys, index); err == nil {
+			return index, true
+		}
+	}
+
+	// SPA route, not a missing asset.
+	if path.Ext(name) == "" {
+		return indexFile, true
+	}
+	return "", false
+}
+
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request, name string) {
+	h := w.Header()
+
+	// Precompressed variant, if the client accepts any.
+	var available []string
+	for _, encoding := range precompressedEncodings {
+		if _, err := fs.Stat(s.fsys, name+precompressed[encoding]); err == nil {
+			available = append(available, encoding)
+		}
+	}
+	variant := name
+	if len(available) > 0 {
+		h.Add("Vary", "Accept-Encoding")
+		if encoding := compress.Negotiate(r.Header.Get("Accept-Encoding"), available); encoding != "" {
+			variant = name + precompressed[encoding]
+			h.Set("Content-Encoding", encoding)
+		}
+	}
+
+	content, modTime, etag, err := s.open(variant)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if closer, ok := content.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	if s.reload != nil && name == indexFile {
+		// Uncompressed, the script is injected into it.
+		h.Del("Content-Encoding")
+		if content, err = s.reload.inject(s.fsys, name, s.prefix); err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		etag = ""
+	}
+
+	if ctype := mime.TypeByExtension(path.Ext(name)); ctype != "" {
+		h.Set("Content-Type", ctype)
+	}
+	if etag != "" {
+		h.Set("ETag", etag)
+	}
+	// Undo middleware.NoCache, in case it ran.
+	h.Del("Pragma")
+	h.Del("Expires")
+	h.Del("X-Accel-Expires")
+	if s.immutable(name) {
+		h.Set("Cache-Control", cacheImmutable)
+	} else {
+		h.Set("Cache-Control", cacheRevalidate)
+	}
+
+	// Handles If-None-Match, If-Modified-Since, HEAD and Range.
+	http.ServeContent(w, r, name, modTime, content)
+}
+
+// open returns file content with its ETag. ETags of embedded files are
+// content hashes, computed once, as the files have no modification time.
+func (s *Server) open(name string) (io.ReadSeeker, time.Time, string, error) {
+	f, err := s.fsys.Open(name)
+	if err != nil {
+		return nil, time.Time{}, "", err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, time.Time{}, "", err
+	}
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		f.Close()
+		return nil, time.Time{}, "", errors.New("file is not seekable")
+	}
+
+	if s.reload != nil {
+		etag := fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size())
+		return content, info.ModTime(), etag, nil
+	}
+
+	if etag, ok := s.etags.Load(name); ok {
+		return content, info.ModTime(), etag.(string), nil
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		f.Close()
+		return nil, time.Time{}, "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		return nil, time.Time{}, "", err
+	}
+	etag := `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+	s.etags.Store(name, etag)
+
+	return content, info.ModTime(), etag, nil
+}
+
+func (s *Server) immutable(name string) bool {
+	for _, prefix := range s.conf.ImmutablePaths {
+		if strings.HasPrefix("/"+name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Close stops watching files and ends live reload streams in dev mode, so
+// they don't hold graceful shutdown.
+func (s *Server) Close() {
+	if s.reload != nil {
+		s.reload.close()
+	}
+}
@@ -16,7 +16,7 @@ type HealthProbe struct {
 	Subject string
 }
 
-func (p *HealthProbe) Run(_ context.Context) Result {
+func (p *HealthProbe) Run(ctx context.Context) Result {
 	// creates NATS inbox where services can reply back with their healthz status
 	replyInbox := natsio.NewInbox()
 
@@ -44,7 +44,7 @@ func (p *HealthProbe) Run(_ context.Context) Result {
 	}()
 
 	// publishes a message to the service healthz subscriber with a temporary inbox address waiting for the replies
-	if err := nats.PublishCoreNATS(p.Subject, ServiceStats{ReplyInbox: replyInbox}); err != nil {
+	if err := nats.PublishCoreNATS(ctx, p.Subject, ServiceStats{ReplyInbox: replyInbox}); err != nil {
 		return Result{
 			Status: ProbeStatusError,
 			Info:   fmt.Errorf("failed to send ping request: %w", err).Error(),
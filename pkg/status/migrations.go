@@ -0,0 +1,38 @@
+package status
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/upper/db/v4"
+
+	"github.com/golang-cz/skeleton/data/migration"
+)
+
+// Migrations reports whether the DB schema matches migrations embedded in
+// the running binary.
+type Migrations struct {
+	GetDB func() db.Session
+}
+
+var _ Probe = &Migrations{}
+
+func (p *Migrations) Run(ctx context.Context) Result {
+	schemaStatus, err := migration.Status(ctx, p.GetDB().Driver().(*sql.DB))
+	if err != nil {
+		return Result{
+			Status: ProbeStatusError,
+			Info:   err.Error(),
+		}
+	}
+
+	status := ProbeStatusHealthy
+	if !schemaStatus.IsCurrent() {
+		status = ProbeStatusWarning
+	}
+
+	return Result{
+		Status: status,
+		Info:   schemaStatus.String(),
+	}
+}
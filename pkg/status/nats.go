@@ -0,0 +1,27 @@
+package status
+
+import (
+	"context"
+
+	"github.com/golang-cz/skeleton/pkg/nats"
+)
+
+// NATS reports whether the NATS client is connected.
+type NATS struct{}
+
+var _ Probe = &NATS{}
+
+func (p *NATS) Run(_ context.Context) Result {
+	conn := nats.Conn()
+	if conn == nil || !conn.IsConnected() {
+		return Result{
+			Status: ProbeStatusError,
+			Info:   "not connected",
+		}
+	}
+
+	return Result{
+		Status: ProbeStatusHealthy,
+		Info:   "connected to " + conn.ConnectedUrlRedacted(),
+	}
+}
@@ -1,6 +1,7 @@
 package status
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"runtime"
@@ -12,8 +13,8 @@ import (
 const megaByte = 1 << (10 * 2)
 
 func HealthSubscriber(subject string) error {
-	if err := nats.SubscribeCoreNATS(subject, func(subject string, req *ServiceStats) error {
-		if err := nats.PublishCoreNATS(req.ReplyInbox, GetServiceStats()); err != nil {
+	if err := nats.SubscribeCoreNATS(subject, func(ctx context.Context, subject string, req *ServiceStats) error {
+		if err := nats.PublishCoreNATS(ctx, req.ReplyInbox, GetServiceStats()); err != nil {
 			return fmt.Errorf("failed to publish healthz reply: %w", err)
 		}
 		return nil
@@ -17,6 +17,12 @@ var templateHTML = `<!DOCTYPE html>
   <body>
     <section class="section">
       <div class="container">
+        {{ with .Maintenance }}{{ if .Enabled }}
+        <div class="notification is-warning">
+          <strong>Maintenance mode</strong>{{ with .Reason }}: {{ html . }}{{ end }}
+          {{ with .ETA }}<br>Expected to end at {{ .Format "2006-01-02 15:04 MST" }}{{ end }}
+        </div>
+        {{ end }}{{ end }}
 <table class="table">
           <thead>
             <tr>
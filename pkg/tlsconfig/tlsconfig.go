@@ -0,0 +1,210 @@
+// Package tlsconfig builds TLS config of servers from certificate files and
+// reloads it when the files change, eg. after certificate renewal.
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-cz/skeleton/config"
+	"github.com/golang-cz/skeleton/pkg/slogger"
+)
+
+const defaultReloadInterval = 10 * time.Second
+
+var minVersions = map[string]uint16{
+	"":    tls.VersionTLS12,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"none":            tls.NoClientCert,
+	"request":         tls.RequestClientCert,
+	"verify_if_given": tls.VerifyClientCertIfGiven,
+	"require":         tls.RequireAndVerifyClientCert,
+}
+
+// Reloader holds TLS config loaded from files. Handshakes use the config
+// loaded last, failed reloads keep the previous one.
+type Reloader struct {
+	conf       config.TLS
+	minVersion uint16
+	clientAuth tls.ClientAuthType
+
+	current atomic.Pointer[tls.Config]
+	modTime time.Time // Latest of the files, when they were loaded.
+	cancel  context.CancelFunc
+}
+
+// New loads the files and checks them for changes periodically.
+func New(conf config.TLS) (*Reloader, error) {
+	if conf.CertFile == "" || conf.KeyFile == "" {
+		return nil, errors.New("cert_file and key_file are required")
+	}
+
+	minVersion, ok := minVersions[conf.MinVersion]
+	if !ok {
+		return nil, fmt.Errorf("unsupported min_version %q", conf.MinVersion)
+	}
+
+	clientAuth := tls.NoClientCert
+	if conf.ClientCAFile != "" {
+		clientAuth = tls.RequireAndVerifyClientCert
+	}
+	if conf.ClientAuth != "" {
+		clientAuth, ok = clientAuthTypes[conf.ClientAuth]
+		if !ok {
+			return nil, fmt.Errorf("unsupported client_auth %q", conf.ClientAuth)
+		}
+	}
+	if clientAuth >= tls.VerifyClientCertIfGiven && conf.ClientCAFile == "" {
+		return nil, fmt.Errorf("client_auth %q requires client_ca_file", conf.ClientAuth)
+	}
+
+	rl := &Reloader{
+		conf:       conf,
+		minVersion: minVersion,
+		clientAuth: clientAuth,
+	}
+	if err := rl.reload(); err != nil {
+		return nil, err
+	}
+
+	interval := time.Duration(conf.ReloadInterval)
+	if interval <= 0 {
+		interval = defaultReloadInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	rl.cancel = cancel
+	go rl.run(ctx, interval)
+
+	return rl, nil
+}
+
+// Config returns TLS config of the server, which uses the files loaded last
+// on each handshake.
+func (rl *Reloader) Config() *tls.Config {
+	return &tls.Config{
+		MinVersion: rl.minVersion,
+		NextProtos: []string{"h2", "http/1.1"},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return rl.current.Load(), nil
+		},
+		// Unused as GetConfigForClient returns the certificate, but net/http
+		// requires a certificate source to serve TLS.
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return &rl.current.Load().Certificates[0], nil
+		},
+	}
+}
+
+// reload loads the files.
+func (rl *Reloader) reload() error {
+	modTime, err := rl.latestModTime()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(rl.conf.CertFile, rl.conf.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load certificate: %w", err)
+	}
+
+	tlsConf := &tls.Config{
+		MinVersion:   rl.minVersion,
+		NextProtos:   []string{"h2", "http/1.1"},
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   rl.clientAuth,
+	}
+
+	if rl.conf.ClientCAFile != "" {
+		pem, err := os.ReadFile(rl.conf.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("read client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("client CA %v has no certificates", rl.conf.ClientCAFile)
+		}
+		tlsConf.ClientCAs = pool
+	}
+
+	rl.current.Store(tlsConf)
+	rl.modTime = modTime
+
+	return nil
+}
+
+// Certificate returns the certificate currently served.
+func (rl *Reloader) Certificate() (*x509.Certificate, error) {
+	cert := rl.current.Load().Certificates[0]
+	if cert.Leaf != nil {
+		return cert.Leaf, nil
+	}
+	return x509.ParseCertificate(cert.Certificate[0])
+}
+
+func (rl *Reloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, name := range []string{rl.conf.CertFile, rl.conf.KeyFile, rl.conf.ClientCAFile} {
+		if name == "" {
+			continue
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+func (rl *Reloader) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		modTime, err := rl.latestModTime()
+		if err != nil || modTime.Equal(rl.modTime) {
+			// Missing files are likely being replaced, try again later.
+			continue
+		}
+
+		if err := rl.reload(); err != nil {
+			// Not retried until the files change again.
+			rl.modTime = modTime
+			err = fmt.Errorf("reload TLS certificate: %w", err)
+			slog.Error(slogger.ErrorCause(err).Error())
+			continue
+		}
+
+		attrs := []any{slog.String("certFile", rl.conf.CertFile)}
+		if cert, err := rl.Certificate(); err == nil {
+			attrs = append(attrs, slog.Time("notAfter", cert.NotAfter))
+		}
+		slog.Info("TLS certificate reloaded", attrs...)
+	}
+}
+
+// Close stops checking the files.
+func (rl *Reloader) Close() {
+	if rl.cancel != nil {
+		rl.cancel()
+	}
+}
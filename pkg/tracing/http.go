@@ -0,0 +1,82 @@
+package tracing
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HTTP starts server span of the request, continuing trace of the caller
+// sent in traceparent header. The span is named by chi route pattern, so
+// mount it on the top-level router.
+func HTTP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		ctx, span := Tracer().Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethod(r.Method),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		if rctx := chi.RouteContext(ctx); rctx != nil && rctx.RoutePattern() != "" {
+			span.SetName(r.Method + " " + rctx.RoutePattern())
+			span.SetAttributes(semconv.HTTPRoute(rctx.RoutePattern()))
+		}
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		span.SetAttributes(semconv.HTTPStatusCode(status))
+		if status >= 500 {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
+
+// RPC starts span of the webrpc method call, named eg. "Skeleton/GetUser".
+// Calls of methods not in given list are named "unknown/unknown". Mark
+// failed calls with RecordError from the webrpc OnError hook.
+func RPC(methods []string) func(http.Handler) http.Handler {
+	known := make(map[string]bool, len(methods))
+	for _, m := range methods {
+		known[m] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			service, method := path.Split(strings.TrimSuffix(r.URL.Path, "/"))
+			service = path.Base(service)
+			if !known[method] {
+				service, method = "unknown", "unknown"
+			}
+
+			ctx, span := Tracer().Start(r.Context(), service+"/"+method,
+				trace.WithAttributes(
+					attribute.String("rpc.system", "webrpc"),
+					semconv.RPCService(service),
+					semconv.RPCMethod(method),
+				),
+			)
+			defer span.End()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,129 @@
+// Package tracing sets up OpenTelemetry tracing. Spans are started by the
+// HTTP middlewares in this package, by DB and NATS clients and by the
+// scheduler, all of them using Tracer.
+//
+// Trace context is propagated in W3C traceparent headers, both in HTTP
+// requests and NATS messages.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/golang-cz/skeleton/config"
+)
+
+const instrumentationName = "github.com/golang-cz/skeleton"
+
+var provider *sdktrace.TracerProvider
+
+// Tracer returns tracer of the global tracer provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Setup installs global tracer provider exporting spans as configured.
+// Trace context is propagated even if tracing is disabled, so traces of
+// callers aren't broken by services not exporting their spans.
+func Setup(conf *config.Config, service, version string) error {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if !conf.Tracing.Enabled {
+		return nil
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(service),
+		semconv.ServiceVersion(version),
+		semconv.DeploymentEnvironment(conf.Environment.String()),
+	))
+	if err != nil {
+		return fmt.Errorf("create resource: %w", err)
+	}
+
+	exporter, err := newExporter(conf.Tracing)
+	if err != nil {
+		return fmt.Errorf("create %q exporter: %w", conf.Tracing.Exporter, err)
+	}
+
+	provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(conf.Tracing.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return nil
+}
+
+func newExporter(conf config.Tracing) (sdktrace.SpanExporter, error) {
+	switch conf.Exporter {
+	case "otlp":
+		if conf.Endpoint == "" {
+			return nil, errors.New("no endpoint")
+		}
+		return newOTLPExporter(conf.Endpoint, conf.Headers), nil
+
+	case "stdout":
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+
+	case "file":
+		f, err := os.OpenFile(conf.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("open file: %w", err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &fileExporter{SpanExporter: exporter, file: f}, nil
+	}
+
+	return nil, errors.New("unknown exporter")
+}
+
+// fileExporter closes the file on shutdown.
+type fileExporter struct {
+	sdktrace.SpanExporter
+	file *os.File
+}
+
+func (e *fileExporter) Shutdown(ctx context.Context) error {
+	err := e.SpanExporter.Shutdown(ctx)
+	return errors.Join(err, e.file.Close())
+}
+
+// Shutdown exports spans not exported yet. Spans ended afterwards are dropped.
+func Shutdown(ctx context.Context) error {
+	if provider == nil {
+		return nil
+	}
+
+	if err := provider.Shutdown(ctx); err != nil {
+		return fmt.Errorf("shutdown tracer provider: %w", err)
+	}
+
+	return nil
+}
+
+// RecordError marks span in ctx as failed with given error.
+func RecordError(ctx context.Context, err error) {
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -45,7 +45,7 @@ func HTML(w http.ResponseWriter, status int, data interface{}) {
 	case reflect.Slice:
 		w.Write(v.Interface().([]byte))
 	default:
-		alert.Msgf(context.Background(), "invalid response data type:", v.Kind())
+		alert.Msgf(context.Background(), "invalid response data type: %v", v.Kind())
 	}
 }
 
@@ -1,8 +1,9 @@
-//go:generate go run github.com/golang-cz/gospeak/cmd/gospeak ./
+//go:generate go run gen.go
 package proto
 
 import (
 	"context"
+	"time"
 )
 
 //go:webrpc json -out=./docs/skeletonApi.webrpc.json
@@ -10,10 +11,41 @@ import (
 //go:webrpc golang@v0.13.5 -client -pkg=skeleton -out=./client/skeleton/skeletonClient.gen.go
 type Skeleton interface {
 	Users
+	ApiKeys
+	Roles
 }
 
 //go:webrpc openapi -title=SkeletonUsersAPI -serverUrl=https://dev.golang.cz/_api -out=./docs/skeletonUsersApi.gen.yaml
 //go:webrpc typescript -client -out=./client/users/skeletonUsersClient.gen.ts
 type Users interface {
+	// Deleted users aren't returned, see ListUsers.
 	GetUser(ctx context.Context, id string) (user *User, err error)
+	CreateUser(ctx context.Context, input *UserInput) (user *User, err error)
+	// Only fields listed in fieldMask, eg. ["email"], are updated.
+	UpdateUser(ctx context.Context, id string, input *UserInput, fieldMask []string) (user *User, err error)
+	// Deleted users are kept, they can be restored.
+	DeleteUser(ctx context.Context, id string) (err error)
+	RestoreUser(ctx context.Context, id string) (user *User, err error)
+	// Sort is "createdAt" (default) or "updatedAt", "-" prefix sorts in
+	// descending order. Pass nextCursor of the previous page as cursor to
+	// get the next page, it's empty on the last page.
+	ListUsers(ctx context.Context, filter *UsersFilter, sort string, cursor string, limit int) (users []*User, nextCursor string, err error)
+}
+
+// Users list and revoke only API keys they created.
+type ApiKeys interface {
+	// The key is returned only here, it can't be retrieved later. Zero
+	// expiresAt means the key doesn't expire.
+	CreateApiKey(ctx context.Context, name string, scopes []string, expiresAt time.Time) (apiKey *ApiKey, key string, err error)
+	ListApiKeys(ctx context.Context) (apiKeys []*ApiKey, err error)
+	RevokeApiKey(ctx context.Context, id string) (err error)
+}
+
+// Roles of users are scoped per application, all methods work with the
+// application of the token.
+type Roles interface {
+	// Roles and permissions of the current user.
+	GetMyPermissions(ctx context.Context) (roles []string, permissions []string, err error)
+	AssignRole(ctx context.Context, userId string, role string) (err error)
+	UnassignRole(ctx context.Context, userId string, role string) (err error)
 }
@@ -0,0 +1,20 @@
+package proto
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// ApiKey authenticates machine callers. The key itself is shown only once,
+// on creation, the prefix identifies it afterwards.
+type ApiKey struct {
+	ID         uuid.UUID  `db:"id,omitempty,pk" json:"id"`
+	Name       string     `db:"name"            json:"name"`
+	Prefix     string     `db:"prefix"          json:"prefix"`
+	Scopes     []string   `db:"scopes"          json:"scopes"`
+	ExpiresAt  *time.Time `db:"expires_at"      json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `db:"last_used_at"    json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `db:"revoked_at"      json:"revokedAt,omitempty"`
+	CreatedAt  time.Time  `db:"created_at"      json:"createdAt"`
+}
@@ -1,4 +1,4 @@
-// Skeleton  8615f6aa36b62f32354b929bd82b79c533250d22
+// Skeleton  308e10e1f3b4db36c9973abb33ea95cbf5803976
 // --
 // Code generated by webrpc-gen@v0.13.0-dev with golang@v0.13.5 generator. DO NOT EDIT.
 //
@@ -14,6 +14,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/gofrs/uuid/v5"
 	
@@ -31,27 +32,76 @@ func WebRPCSchemaVersion() string {
 
 // Schema hash generated from your RIDL schema
 func WebRPCSchemaHash() string {
-	return "8615f6aa36b62f32354b929bd82b79c533250d22"
+	return "308e10e1f3b4db36c9973abb33ea95cbf5803976"
 }
 
 //
 // Types
 //
 
+type ApiKey struct {
+	ID uuid.UUID `json:"id"`
+	Name string `json:"name"`
+	Prefix string `json:"prefix"`
+	Scopes []string `json:"scopes"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type UserInput struct {
+	Email string `json:"email"`
+	Firstname string `json:"firstname"`
+	Lastname string `json:"lastname"`
+}
+
 type User struct {
 	ID uuid.UUID `json:"id"`
 	Email string `json:"email"`
 	Firstname string `json:"firstname"`
 	Lastname string `json:"lastname"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+}
+
+type UsersFilter struct {
+	Email string `json:"email"`
+	Status string `json:"status"`
+	CreatedAfter *time.Time `json:"createdAfter,omitempty"`
+	CreatedBefore *time.Time `json:"createdBefore,omitempty"`
 }
 
 type Skeleton interface {
+	AssignRole(ctx context.Context, userId string, role string) (error)
+	CreateApiKey(ctx context.Context, name string, scopes []string, expiresAt time.Time) (*ApiKey, string, error)
+	CreateUser(ctx context.Context, input *UserInput) (*User, error)
+	DeleteUser(ctx context.Context, id string) (error)
+	GetMyPermissions(ctx context.Context) ([]string, []string, error)
 	GetUser(ctx context.Context, id string) (*User, error)
+	ListApiKeys(ctx context.Context) ([]*ApiKey, error)
+	ListUsers(ctx context.Context, filter *UsersFilter, sort string, cursor string, limit int) ([]*User, string, error)
+	RestoreUser(ctx context.Context, id string) (*User, error)
+	RevokeApiKey(ctx context.Context, id string) (error)
+	UnassignRole(ctx context.Context, userId string, role string) (error)
+	UpdateUser(ctx context.Context, id string, input *UserInput, fieldMask []string) (*User, error)
 }
 
 var WebRPCServices = map[string][]string{
 	"Skeleton": {
+		"AssignRole",
+		"CreateApiKey",
+		"CreateUser",
+		"DeleteUser",
+		"GetMyPermissions",
 		"GetUser",
+		"ListApiKeys",
+		"ListUsers",
+		"RestoreUser",
+		"RevokeApiKey",
+		"UnassignRole",
+		"UpdateUser",
 	},
 }
 
@@ -63,13 +113,24 @@ const SkeletonPathPrefix = "/rpc/Skeleton/"
 
 type skeletonClient struct {
 	client HTTPClient
-	urls	 [1]string
+	urls	 [12]string
 }
 
 func NewSkeletonClient(addr string, client HTTPClient) Skeleton {
 	prefix := urlBase(addr) + SkeletonPathPrefix
-	urls := [1]string{
+	urls := [12]string{
+		prefix + "AssignRole",
+		prefix + "CreateApiKey",
+		prefix + "CreateUser",
+		prefix + "DeleteUser",
+		prefix + "GetMyPermissions",
 		prefix + "GetUser",
+		prefix + "ListApiKeys",
+		prefix + "ListUsers",
+		prefix + "RestoreUser",
+		prefix + "RevokeApiKey",
+		prefix + "UnassignRole",
+		prefix + "UpdateUser",
 	}
 	return &skeletonClient{
 		client: client,
@@ -77,6 +138,62 @@ func NewSkeletonClient(addr string, client HTTPClient) Skeleton {
 	}
 }
 
+func (c *skeletonClient) AssignRole(ctx context.Context, userId string, role string) (error) {
+	in := struct {
+		Arg0 string `json:"userId"`
+		Arg1 string `json:"role"`
+	}{userId, role}
+
+	err := doJSONRequest(ctx, c.client, c.urls[0], in, nil)
+	return err
+}
+
+func (c *skeletonClient) CreateApiKey(ctx context.Context, name string, scopes []string, expiresAt time.Time) (*ApiKey, string, error) {
+	in := struct {
+		Arg0 string `json:"name"`
+		Arg1 []string `json:"scopes"`
+		Arg2 time.Time `json:"expiresAt"`
+	}{name, scopes, expiresAt}
+	out := struct {
+		Ret0 *ApiKey `json:"apiKey"`
+		Ret1 string `json:"key"`
+	}{}
+	
+	err := doJSONRequest(ctx, c.client, c.urls[1], in, &out)
+	return out.Ret0, out.Ret1, err
+}
+
+func (c *skeletonClient) CreateUser(ctx context.Context, input *UserInput) (*User, error) {
+	in := struct {
+		Arg0 *UserInput `json:"input"`
+	}{input}
+	out := struct {
+		Ret0 *User `json:"user"`
+	}{}
+	
+	err := doJSONRequest(ctx, c.client, c.urls[2], in, &out)
+	return out.Ret0, err
+}
+
+func (c *skeletonClient) DeleteUser(ctx context.Context, id string) (error) {
+	in := struct {
+		Arg0 string `json:"id"`
+	}{id}
+
+	err := doJSONRequest(ctx, c.client, c.urls[3], in, nil)
+	return err
+}
+
+func (c *skeletonClient) GetMyPermissions(ctx context.Context) ([]string, []string, error) {
+	out := struct {
+		Ret0 []string `json:"roles"`
+		Ret1 []string `json:"permissions"`
+	}{}
+	
+	err := doJSONRequest(ctx, c.client, c.urls[4], nil, &out)
+	return out.Ret0, out.Ret1, err
+}
+
 func (c *skeletonClient) GetUser(ctx context.Context, id string) (*User, error) {
 	in := struct {
 		Arg0 string `json:"id"`
@@ -85,7 +202,77 @@ func (c *skeletonClient) GetUser(ctx context.Context, id string) (*User, error)
 		Ret0 *User `json:"user"`
 	}{}
 	
-	err := doJSONRequest(ctx, c.client, c.urls[0], in, &out)
+	err := doJSONRequest(ctx, c.client, c.urls[5], in, &out)
+	return out.Ret0, err
+}
+
+func (c *skeletonClient) ListApiKeys(ctx context.Context) ([]*ApiKey, error) {
+	out := struct {
+		Ret0 []*ApiKey `json:"apiKeys"`
+	}{}
+	
+	err := doJSONRequest(ctx, c.client, c.urls[6], nil, &out)
+	return out.Ret0, err
+}
+
+func (c *skeletonClient) ListUsers(ctx context.Context, filter *UsersFilter, sort string, cursor string, limit int) ([]*User, string, error) {
+	in := struct {
+		Arg0 *UsersFilter `json:"filter"`
+		Arg1 string `json:"sort"`
+		Arg2 string `json:"cursor"`
+		Arg3 int `json:"limit"`
+	}{filter, sort, cursor, limit}
+	out := struct {
+		Ret0 []*User `json:"users"`
+		Ret1 string `json:"nextCursor"`
+	}{}
+	
+	err := doJSONRequest(ctx, c.client, c.urls[7], in, &out)
+	return out.Ret0, out.Ret1, err
+}
+
+func (c *skeletonClient) RestoreUser(ctx context.Context, id string) (*User, error) {
+	in := struct {
+		Arg0 string `json:"id"`
+	}{id}
+	out := struct {
+		Ret0 *User `json:"user"`
+	}{}
+	
+	err := doJSONRequest(ctx, c.client, c.urls[8], in, &out)
+	return out.Ret0, err
+}
+
+func (c *skeletonClient) RevokeApiKey(ctx context.Context, id string) (error) {
+	in := struct {
+		Arg0 string `json:"id"`
+	}{id}
+
+	err := doJSONRequest(ctx, c.client, c.urls[9], in, nil)
+	return err
+}
+
+func (c *skeletonClient) UnassignRole(ctx context.Context, userId string, role string) (error) {
+	in := struct {
+		Arg0 string `json:"userId"`
+		Arg1 string `json:"role"`
+	}{userId, role}
+
+	err := doJSONRequest(ctx, c.client, c.urls[10], in, nil)
+	return err
+}
+
+func (c *skeletonClient) UpdateUser(ctx context.Context, id string, input *UserInput, fieldMask []string) (*User, error) {
+	in := struct {
+		Arg0 string `json:"id"`
+		Arg1 *UserInput `json:"input"`
+		Arg2 []string `json:"fieldMask"`
+	}{id, input, fieldMask}
+	out := struct {
+		Ret0 *User `json:"user"`
+	}{}
+	
+	err := doJSONRequest(ctx, c.client, c.urls[11], in, &out)
 	return out.Ret0, err
 }
 
@@ -310,3 +497,16 @@ var (
 	ErrWebrpcInternalError = WebRPCError{Code: -7, Name: "WebrpcInternalError", Message: "internal error", HTTPStatus: 500}
 )
 
+// Schema errors
+var (
+	ErrUnauthenticated = WebRPCError{Code: 1001, Name: "Unauthenticated", Message: "unauthenticated", HTTPStatus: 401}
+	ErrPermissionDenied = WebRPCError{Code: 1002, Name: "PermissionDenied", Message: "permission denied", HTTPStatus: 403}
+	ErrNotFound = WebRPCError{Code: 1003, Name: "NotFound", Message: "not found", HTTPStatus: 404}
+	ErrRateLimited = WebRPCError{Code: 1004, Name: "RateLimited", Message: "rate limit exceeded", HTTPStatus: 429}
+	ErrIdempotencyKeyReused = WebRPCError{Code: 1005, Name: "IdempotencyKeyReused", Message: "idempotency key was used for a different request", HTTPStatus: 422}
+	ErrIdempotencyKeyInProgress = WebRPCError{Code: 1006, Name: "IdempotencyKeyInProgress", Message: "request with the idempotency key is in progress", HTTPStatus: 409}
+	ErrMaintenance = WebRPCError{Code: 1007, Name: "Maintenance", Message: "service is under maintenance", HTTPStatus: 503}
+	ErrInvalidArgument = WebRPCError{Code: 1008, Name: "InvalidArgument", Message: "invalid argument", HTTPStatus: 400}
+	ErrConflict = WebRPCError{Code: 1009, Name: "Conflict", Message: "conflict with current state", HTTPStatus: 409}
+)
+
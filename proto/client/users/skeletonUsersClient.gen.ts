@@ -1,5 +1,5 @@
 /* eslint-disable */
-// Users  0a8954d2b291b48d0b71d59af8c86d10dbbcf426
+// Users  49d769dd0568899b4d74f06a354d0c673856e3ba
 // --
 // Code generated by webrpc-gen@v0.13.0-dev with typescript generator. DO NOT EDIT.
 //
@@ -12,22 +12,58 @@ export const WebRPCVersion = "v1"
 export const WebRPCSchemaVersion = ""
 
 // Schema hash generated from your RIDL schema
-export const WebRPCSchemaHash = "0a8954d2b291b48d0b71d59af8c86d10dbbcf426"
+export const WebRPCSchemaHash = "49d769dd0568899b4d74f06a354d0c673856e3ba"
 
 //
 // Types
 //
 
 
+export interface UserInput {
+  email: string
+  firstname: string
+  lastname: string
+}
+
 export interface User {
   id: string
   email: string
   firstname: string
   lastname: string
+  createdAt: string
+  updatedAt: string
+  deletedAt?: string
+}
+
+export interface UsersFilter {
+  email: string
+  status: string
+  createdAfter?: string
+  createdBefore?: string
 }
 
 export interface Users {
+  createUser(args: CreateUserArgs, headers?: object, signal?: AbortSignal): Promise<CreateUserReturn>
+  deleteUser(args: DeleteUserArgs, headers?: object, signal?: AbortSignal): Promise<DeleteUserReturn>
   getUser(args: GetUserArgs, headers?: object, signal?: AbortSignal): Promise<GetUserReturn>
+  listUsers(args: ListUsersArgs, headers?: object, signal?: AbortSignal): Promise<ListUsersReturn>
+  restoreUser(args: RestoreUserArgs, headers?: object, signal?: AbortSignal): Promise<RestoreUserReturn>
+  updateUser(args: UpdateUserArgs, headers?: object, signal?: AbortSignal): Promise<UpdateUserReturn>
+}
+
+export interface CreateUserArgs {
+  input: UserInput
+}
+
+export interface CreateUserReturn {
+  user: User  
+}
+
+export interface DeleteUserArgs {
+  id: string
+}
+
+export interface DeleteUserReturn {
 }
 
 export interface GetUserArgs {
@@ -38,6 +74,36 @@ export interface GetUserReturn {
   user: User  
 }
 
+export interface ListUsersArgs {
+  filter: UsersFilter
+  sort: string
+  cursor: string
+  limit: number
+}
+
+export interface ListUsersReturn {
+  users: Array<User>  
+  nextCursor: string  
+}
+
+export interface RestoreUserArgs {
+  id: string
+}
+
+export interface RestoreUserReturn {
+  user: User  
+}
+
+export interface UpdateUserArgs {
+  id: string
+  input: UserInput
+  fieldMask: Array<string>
+}
+
+export interface UpdateUserReturn {
+  user: User  
+}
+
 
   
 //
@@ -57,6 +123,32 @@ export class Users implements Users {
     return this.hostname + this.path + name
   }
   
+  createUser = (args: CreateUserArgs, headers?: object, signal?: AbortSignal): Promise<CreateUserReturn> => {
+    return this.fetch(
+      this.url('CreateUser'),
+      createHTTPRequest(args, headers, signal)).then((res) => {
+      return buildResponse(res).then(_data => {
+        return {
+          user: <User>(_data.user),
+        }
+      })
+    }, (error) => {
+      throw WebrpcRequestFailedError.new({ cause: `fetch(): ${error.message || ''}` })
+    })
+  }
+  
+  deleteUser = (args: DeleteUserArgs, headers?: object, signal?: AbortSignal): Promise<DeleteUserReturn> => {
+    return this.fetch(
+      this.url('DeleteUser'),
+      createHTTPRequest(args, headers, signal)).then((res) => {
+      return buildResponse(res).then(_data => {
+        return {}
+      })
+    }, (error) => {
+      throw WebrpcRequestFailedError.new({ cause: `fetch(): ${error.message || ''}` })
+    })
+  }
+  
   getUser = (args: GetUserArgs, headers?: object, signal?: AbortSignal): Promise<GetUserReturn> => {
     return this.fetch(
       this.url('GetUser'),
@@ -71,6 +163,49 @@ export class Users implements Users {
     })
   }
   
+  listUsers = (args: ListUsersArgs, headers?: object, signal?: AbortSignal): Promise<ListUsersReturn> => {
+    return this.fetch(
+      this.url('ListUsers'),
+      createHTTPRequest(args, headers, signal)).then((res) => {
+      return buildResponse(res).then(_data => {
+        return {
+          users: <Array<User>>(_data.users),
+          nextCursor: <string>(_data.nextCursor),
+        }
+      })
+    }, (error) => {
+      throw WebrpcRequestFailedError.new({ cause: `fetch(): ${error.message || ''}` })
+    })
+  }
+  
+  restoreUser = (args: RestoreUserArgs, headers?: object, signal?: AbortSignal): Promise<RestoreUserReturn> => {
+    return this.fetch(
+      this.url('RestoreUser'),
+      createHTTPRequest(args, headers, signal)).then((res) => {
+      return buildResponse(res).then(_data => {
+        return {
+          user: <User>(_data.user),
+        }
+      })
+    }, (error) => {
+      throw WebrpcRequestFailedError.new({ cause: `fetch(): ${error.message || ''}` })
+    })
+  }
+  
+  updateUser = (args: UpdateUserArgs, headers?: object, signal?: AbortSignal): Promise<UpdateUserReturn> => {
+    return this.fetch(
+      this.url('UpdateUser'),
+      createHTTPRequest(args, headers, signal)).then((res) => {
+      return buildResponse(res).then(_data => {
+        return {
+          user: <User>(_data.user),
+        }
+      })
+    }, (error) => {
+      throw WebrpcRequestFailedError.new({ cause: `fetch(): ${error.message || ''}` })
+    })
+  }
+  
 }
 
   const createHTTPRequest = (body: object = {}, headers: object = {}, signal: AbortSignal | null = null): object => {
@@ -244,6 +379,123 @@ export class WebrpcInternalErrorError extends WebrpcError {
 
 // Schema errors
 
+export class UnauthenticatedError extends WebrpcError {
+  constructor(
+    name: string = 'Unauthenticated',
+    code: number = 1001,
+    message: string = 'unauthenticated',
+    status: number = 0,
+    cause?: string
+  ) {
+    super(name, code, message, status, cause)
+    Object.setPrototypeOf(this, UnauthenticatedError.prototype)
+  }
+}
+
+export class PermissionDeniedError extends WebrpcError {
+  constructor(
+    name: string = 'PermissionDenied',
+    code: number = 1002,
+    message: string = 'permission denied',
+    status: number = 0,
+    cause?: string
+  ) {
+    super(name, code, message, status, cause)
+    Object.setPrototypeOf(this, PermissionDeniedError.prototype)
+  }
+}
+
+export class NotFoundError extends WebrpcError {
+  constructor(
+    name: string = 'NotFound',
+    code: number = 1003,
+    message: string = 'not found',
+    status: number = 0,
+    cause?: string
+  ) {
+    super(name, code, message, status, cause)
+    Object.setPrototypeOf(this, NotFoundError.prototype)
+  }
+}
+
+export class RateLimitedError extends WebrpcError {
+  constructor(
+    name: string = 'RateLimited',
+    code: number = 1004,
+    message: string = 'rate limit exceeded',
+    status: number = 0,
+    cause?: string
+  ) {
+    super(name, code, message, status, cause)
+    Object.setPrototypeOf(this, RateLimitedError.prototype)
+  }
+}
+
+export class IdempotencyKeyReusedError extends WebrpcError {
+  constructor(
+    name: string = 'IdempotencyKeyReused',
+    code: number = 1005,
+    message: string = 'idempotency key was used for a different request',
+    status: number = 0,
+    cause?: string
+  ) {
+    super(name, code, message, status, cause)
+    Object.setPrototypeOf(this, IdempotencyKeyReusedError.prototype)
+  }
+}
+
+export class IdempotencyKeyInProgressError extends WebrpcError {
+  constructor(
+    name: string = 'IdempotencyKeyInProgress',
+    code: number = 1006,
+    message: string = 'request with the idempotency key is in progress',
+    status: number = 0,
+    cause?: string
+  ) {
+    super(name, code, message, status, cause)
+    Object.setPrototypeOf(this, IdempotencyKeyInProgressError.prototype)
+  }
+}
+
+export class MaintenanceError extends WebrpcError {
+  constructor(
+    name: string = 'Maintenance',
+    code: number = 1007,
+    message: string = 'service is under maintenance',
+    status: number = 0,
+    cause?: string
+  ) {
+    super(name, code, message, status, cause)
+    Object.setPrototypeOf(this, MaintenanceError.prototype)
+  }
+}
+
+export class InvalidArgumentError extends WebrpcError {
+  constructor(
+    name: string = 'InvalidArgument',
+    code: number = 1008,
+    message: string = 'invalid argument',
+    status: number = 0,
+    cause?: string
+  ) {
+    super(name, code, message, status, cause)
+    Object.setPrototypeOf(this, InvalidArgumentError.prototype)
+  }
+}
+
+export class ConflictError extends WebrpcError {
+  constructor(
+    name: string = 'Conflict',
+    code: number = 1009,
+    message: string = 'conflict with current state',
+    status: number = 0,
+    cause?: string
+  ) {
+    super(name, code, message, status, cause)
+    Object.setPrototypeOf(this, ConflictError.prototype)
+  }
+}
+
 
 export enum errors {
   WebrpcEndpoint = 'WebrpcEndpoint',
@@ -254,6 +506,15 @@ export enum errors {
   WebrpcBadResponse = 'WebrpcBadResponse',
   WebrpcServerPanic = 'WebrpcServerPanic',
   WebrpcInternalError = 'WebrpcInternalError',
+  Unauthenticated = 'Unauthenticated',
+  PermissionDenied = 'PermissionDenied',
+  NotFound = 'NotFound',
+  RateLimited = 'RateLimited',
+  IdempotencyKeyReused = 'IdempotencyKeyReused',
+  IdempotencyKeyInProgress = 'IdempotencyKeyInProgress',
+  Maintenance = 'Maintenance',
+  InvalidArgument = 'InvalidArgument',
+  Conflict = 'Conflict',
 }
 
 const webrpcErrorByCode: { [code: number]: any } = {
@@ -265,6 +526,15 @@ const webrpcErrorByCode: { [code: number]: any } = {
   [-5]: WebrpcBadResponseError,
   [-6]: WebrpcServerPanicError,
   [-7]: WebrpcInternalErrorError,
+  [1001]: UnauthenticatedError,
+  [1002]: PermissionDeniedError,
+  [1003]: NotFoundError,
+  [1004]: RateLimitedError,
+  [1005]: IdempotencyKeyReusedError,
+  [1006]: IdempotencyKeyInProgressError,
+  [1007]: MaintenanceError,
+  [1008]: InvalidArgumentError,
+  [1009]: ConflictError,
 }
 
 export type Fetch = (input: RequestInfo, init?: RequestInit) => Promise<Response>
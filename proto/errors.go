@@ -0,0 +1,19 @@
+package proto
+
+import "fmt"
+
+// WithRequestId adds request id to the error cause, so clients can report it
+// and the error can be matched with server logs and Sentry events.
+func (e WebRPCError) WithRequestId(requestId string) WebRPCError {
+	if requestId == "" {
+		return e
+	}
+
+	if e.Cause == "" {
+		e.Cause = fmt.Sprintf("requestId=%s", requestId)
+	} else {
+		e.Cause = fmt.Sprintf("%s (requestId=%s)", e.Cause, requestId)
+	}
+
+	return e
+}
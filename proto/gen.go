@@ -0,0 +1,55 @@
+//go:build ignore
+
+// Generates the Webrpc schema, server, clients and docs like gospeak does,
+// adding the errors declared in errors.ridl to every target, as gospeak
+// can't declare schema errors in Go.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/golang-cz/gospeak"
+	"github.com/webrpc/webrpc/gen"
+	"github.com/webrpc/webrpc/schema/ridl"
+)
+
+func main() {
+	errors, err := ridl.NewParser(os.DirFS("."), "errors.ridl").Parse()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to parse errors.ridl: %v\n", err)
+		os.Exit(1)
+	}
+
+	targets, err := gospeak.Parse("./")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to parse Go schema: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, target := range targets {
+		target.Schema.Errors = append(target.Schema.Errors, errors.Errors...)
+		if err := target.Schema.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid %v schema: %v\n", target.InterfaceName, err)
+			os.Exit(1)
+		}
+
+		config := &gen.Config{
+			RefreshCache:    false,
+			Format:          false,
+			TemplateOptions: target.Opts,
+		}
+
+		generated, err := gen.Generate(target.Schema, target.Generator, config)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		if err := os.WriteFile(target.OutFile, []byte(generated.Code), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write to %q file: %v\n", target.OutFile, err)
+			os.Exit(1)
+		}
+		fmt.Printf("%20v => %v ✓\n", target.InterfaceName, target.OutFile)
+	}
+}
@@ -1,4 +1,4 @@
-// Skeleton  8615f6aa36b62f32354b929bd82b79c533250d22
+// Skeleton  308e10e1f3b4db36c9973abb33ea95cbf5803976
 // --
 // Code generated by webrpc-gen@v0.13.0-dev with golang@v0.13.5 generator. DO NOT EDIT.
 //
@@ -15,6 +15,7 @@ import (
 	"reflect"
 	"runtime"
 	"strings"
+	"time"
 )
 
 // WebRPC description and code-gen version
@@ -29,7 +30,7 @@ func WebRPCSchemaVersion() string {
 
 // Schema hash generated from your RIDL schema
 func WebRPCSchemaHash() string {
-	return "8615f6aa36b62f32354b929bd82b79c533250d22"
+	return "308e10e1f3b4db36c9973abb33ea95cbf5803976"
 }
 
 //
@@ -72,7 +73,18 @@ func (s *skeletonServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	var handler func(ctx context.Context, w http.ResponseWriter, r *http.Request)
 	switch r.URL.Path {
+	case "/rpc/Skeleton/AssignRole": handler = s.serveAssignRoleJSON
+	case "/rpc/Skeleton/CreateApiKey": handler = s.serveCreateApiKeyJSON
+	case "/rpc/Skeleton/CreateUser": handler = s.serveCreateUserJSON
+	case "/rpc/Skeleton/DeleteUser": handler = s.serveDeleteUserJSON
+	case "/rpc/Skeleton/GetMyPermissions": handler = s.serveGetMyPermissionsJSON
 	case "/rpc/Skeleton/GetUser": handler = s.serveGetUserJSON
+	case "/rpc/Skeleton/ListApiKeys": handler = s.serveListApiKeysJSON
+	case "/rpc/Skeleton/ListUsers": handler = s.serveListUsersJSON
+	case "/rpc/Skeleton/RestoreUser": handler = s.serveRestoreUserJSON
+	case "/rpc/Skeleton/RevokeApiKey": handler = s.serveRevokeApiKeyJSON
+	case "/rpc/Skeleton/UnassignRole": handler = s.serveUnassignRoleJSON
+	case "/rpc/Skeleton/UpdateUser": handler = s.serveUpdateUserJSON
 	default:
 		err := ErrWebrpcBadRoute.WithCause(fmt.Errorf("no handler for path %q", r.URL.Path))
 		s.sendErrorJSON(w, r, err)
@@ -101,6 +113,193 @@ func (s *skeletonServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (s *skeletonServer) serveAssignRoleJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "AssignRole")
+
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to read request data: %w", err)))
+		return
+	}
+	defer r.Body.Close()
+
+	reqPayload := struct {
+		Arg0 string `json:"userId"`
+		Arg1 string `json:"role"`
+	}{}
+	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
+		return
+	}
+
+	// Call service method implementation.
+	err = s.Skeleton.AssignRole(ctx, reqPayload.Arg0, reqPayload.Arg1)
+	if err != nil {
+		rpcErr, ok := err.(WebRPCError)
+		if !ok {
+			rpcErr = ErrWebrpcEndpoint.WithCause(err)
+		}
+		s.sendErrorJSON(w, r, rpcErr)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("{}"))
+}
+
+func (s *skeletonServer) serveCreateApiKeyJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "CreateApiKey")
+
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to read request data: %w", err)))
+		return
+	}
+	defer r.Body.Close()
+
+	reqPayload := struct {
+		Arg0 string `json:"name"`
+		Arg1 []string `json:"scopes"`
+		Arg2 time.Time `json:"expiresAt"`
+	}{}
+	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
+		return
+	}
+
+	// Call service method implementation.
+	ret0, ret1, err := s.Skeleton.CreateApiKey(ctx, reqPayload.Arg0, reqPayload.Arg1, reqPayload.Arg2)
+	if err != nil {
+		rpcErr, ok := err.(WebRPCError)
+		if !ok {
+			rpcErr = ErrWebrpcEndpoint.WithCause(err)
+		}
+		s.sendErrorJSON(w, r, rpcErr)
+		return
+	}
+
+	respPayload := struct {
+		Ret0 *ApiKey `json:"apiKey"`
+		Ret1 string `json:"key"`
+	}{ret0, ret1}
+	respBody, err := json.Marshal(initializeNilSlices(respPayload))
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadResponse.WithCause(fmt.Errorf("failed to marshal json response: %w", err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(respBody)
+}
+
+func (s *skeletonServer) serveCreateUserJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "CreateUser")
+
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to read request data: %w", err)))
+		return
+	}
+	defer r.Body.Close()
+
+	reqPayload := struct {
+		Arg0 *UserInput `json:"input"`
+	}{}
+	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
+		return
+	}
+
+	// Call service method implementation.
+	ret0, err := s.Skeleton.CreateUser(ctx, reqPayload.Arg0)
+	if err != nil {
+		rpcErr, ok := err.(WebRPCError)
+		if !ok {
+			rpcErr = ErrWebrpcEndpoint.WithCause(err)
+		}
+		s.sendErrorJSON(w, r, rpcErr)
+		return
+	}
+
+	respPayload := struct {
+		Ret0 *User `json:"user"`
+	}{ret0}
+	respBody, err := json.Marshal(initializeNilSlices(respPayload))
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadResponse.WithCause(fmt.Errorf("failed to marshal json response: %w", err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(respBody)
+}
+
+func (s *skeletonServer) serveDeleteUserJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "DeleteUser")
+
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to read request data: %w", err)))
+		return
+	}
+	defer r.Body.Close()
+
+	reqPayload := struct {
+		Arg0 string `json:"id"`
+	}{}
+	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
+		return
+	}
+
+	// Call service method implementation.
+	err = s.Skeleton.DeleteUser(ctx, reqPayload.Arg0)
+	if err != nil {
+		rpcErr, ok := err.(WebRPCError)
+		if !ok {
+			rpcErr = ErrWebrpcEndpoint.WithCause(err)
+		}
+		s.sendErrorJSON(w, r, rpcErr)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("{}"))
+}
+
+func (s *skeletonServer) serveGetMyPermissionsJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "GetMyPermissions")
+
+	// Call service method implementation.
+	ret0, ret1, err := s.Skeleton.GetMyPermissions(ctx)
+	if err != nil {
+		rpcErr, ok := err.(WebRPCError)
+		if !ok {
+			rpcErr = ErrWebrpcEndpoint.WithCause(err)
+		}
+		s.sendErrorJSON(w, r, rpcErr)
+		return
+	}
+
+	respPayload := struct {
+		Ret0 []string `json:"roles"`
+		Ret1 []string `json:"permissions"`
+	}{ret0, ret1}
+	respBody, err := json.Marshal(initializeNilSlices(respPayload))
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadResponse.WithCause(fmt.Errorf("failed to marshal json response: %w", err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(respBody)
+}
+
 func (s *skeletonServer) serveGetUserJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 	ctx = context.WithValue(ctx, MethodNameCtxKey, "GetUser")
 
@@ -144,6 +343,238 @@ func (s *skeletonServer) serveGetUserJSON(ctx context.Context, w http.ResponseWr
 	w.Write(respBody)
 }
 
+func (s *skeletonServer) serveListApiKeysJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "ListApiKeys")
+
+	// Call service method implementation.
+	ret0, err := s.Skeleton.ListApiKeys(ctx)
+	if err != nil {
+		rpcErr, ok := err.(WebRPCError)
+		if !ok {
+			rpcErr = ErrWebrpcEndpoint.WithCause(err)
+		}
+		s.sendErrorJSON(w, r, rpcErr)
+		return
+	}
+
+	respPayload := struct {
+		Ret0 []*ApiKey `json:"apiKeys"`
+	}{ret0}
+	respBody, err := json.Marshal(initializeNilSlices(respPayload))
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadResponse.WithCause(fmt.Errorf("failed to marshal json response: %w", err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(respBody)
+}
+
+func (s *skeletonServer) serveListUsersJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "ListUsers")
+
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to read request data: %w", err)))
+		return
+	}
+	defer r.Body.Close()
+
+	reqPayload := struct {
+		Arg0 *UsersFilter `json:"filter"`
+		Arg1 string `json:"sort"`
+		Arg2 string `json:"cursor"`
+		Arg3 int `json:"limit"`
+	}{}
+	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
+		return
+	}
+
+	// Call service method implementation.
+	ret0, ret1, err := s.Skeleton.ListUsers(ctx, reqPayload.Arg0, reqPayload.Arg1, reqPayload.Arg2, reqPayload.Arg3)
+	if err != nil {
+		rpcErr, ok := err.(WebRPCError)
+		if !ok {
+			rpcErr = ErrWebrpcEndpoint.WithCause(err)
+		}
+		s.sendErrorJSON(w, r, rpcErr)
+		return
+	}
+
+	respPayload := struct {
+		Ret0 []*User `json:"users"`
+		Ret1 string `json:"nextCursor"`
+	}{ret0, ret1}
+	respBody, err := json.Marshal(initializeNilSlices(respPayload))
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadResponse.WithCause(fmt.Errorf("failed to marshal json response: %w", err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(respBody)
+}
+
+func (s *skeletonServer) serveRestoreUserJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "RestoreUser")
+
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to read request data: %w", err)))
+		return
+	}
+	defer r.Body.Close()
+
+	reqPayload := struct {
+		Arg0 string `json:"id"`
+	}{}
+	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
+		return
+	}
+
+	// Call service method implementation.
+	ret0, err := s.Skeleton.RestoreUser(ctx, reqPayload.Arg0)
+	if err != nil {
+		rpcErr, ok := err.(WebRPCError)
+		if !ok {
+			rpcErr = ErrWebrpcEndpoint.WithCause(err)
+		}
+		s.sendErrorJSON(w, r, rpcErr)
+		return
+	}
+
+	respPayload := struct {
+		Ret0 *User `json:"user"`
+	}{ret0}
+	respBody, err := json.Marshal(initializeNilSlices(respPayload))
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadResponse.WithCause(fmt.Errorf("failed to marshal json response: %w", err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(respBody)
+}
+
+func (s *skeletonServer) serveRevokeApiKeyJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "RevokeApiKey")
+
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to read request data: %w", err)))
+		return
+	}
+	defer r.Body.Close()
+
+	reqPayload := struct {
+		Arg0 string `json:"id"`
+	}{}
+	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
+		return
+	}
+
+	// Call service method implementation.
+	err = s.Skeleton.RevokeApiKey(ctx, reqPayload.Arg0)
+	if err != nil {
+		rpcErr, ok := err.(WebRPCError)
+		if !ok {
+			rpcErr = ErrWebrpcEndpoint.WithCause(err)
+		}
+		s.sendErrorJSON(w, r, rpcErr)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("{}"))
+}
+
+func (s *skeletonServer) serveUnassignRoleJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "UnassignRole")
+
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to read request data: %w", err)))
+		return
+	}
+	defer r.Body.Close()
+
+	reqPayload := struct {
+		Arg0 string `json:"userId"`
+		Arg1 string `json:"role"`
+	}{}
+	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
+		return
+	}
+
+	// Call service method implementation.
+	err = s.Skeleton.UnassignRole(ctx, reqPayload.Arg0, reqPayload.Arg1)
+	if err != nil {
+		rpcErr, ok := err.(WebRPCError)
+		if !ok {
+			rpcErr = ErrWebrpcEndpoint.WithCause(err)
+		}
+		s.sendErrorJSON(w, r, rpcErr)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("{}"))
+}
+
+func (s *skeletonServer) serveUpdateUserJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "UpdateUser")
+
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to read request data: %w", err)))
+		return
+	}
+	defer r.Body.Close()
+
+	reqPayload := struct {
+		Arg0 string `json:"id"`
+		Arg1 *UserInput `json:"input"`
+		Arg2 []string `json:"fieldMask"`
+	}{}
+	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
+		return
+	}
+
+	// Call service method implementation.
+	ret0, err := s.Skeleton.UpdateUser(ctx, reqPayload.Arg0, reqPayload.Arg1, reqPayload.Arg2)
+	if err != nil {
+		rpcErr, ok := err.(WebRPCError)
+		if !ok {
+			rpcErr = ErrWebrpcEndpoint.WithCause(err)
+		}
+		s.sendErrorJSON(w, r, rpcErr)
+		return
+	}
+
+	respPayload := struct {
+		Ret0 *User `json:"user"`
+	}{ret0}
+	respBody, err := json.Marshal(initializeNilSlices(respPayload))
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadResponse.WithCause(fmt.Errorf("failed to marshal json response: %w", err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(respBody)
+}
+
 
 func (s *skeletonServer) sendErrorJSON(w http.ResponseWriter, r *http.Request, rpcErr WebRPCError) {
 	if s.OnError != nil {
@@ -312,3 +743,16 @@ var (
 	ErrWebrpcInternalError = WebRPCError{Code: -7, Name: "WebrpcInternalError", Message: "internal error", HTTPStatus: 500}
 )
 
+// Schema errors
+var (
+	ErrUnauthenticated = WebRPCError{Code: 1001, Name: "Unauthenticated", Message: "unauthenticated", HTTPStatus: 401}
+	ErrPermissionDenied = WebRPCError{Code: 1002, Name: "PermissionDenied", Message: "permission denied", HTTPStatus: 403}
+	ErrNotFound = WebRPCError{Code: 1003, Name: "NotFound", Message: "not found", HTTPStatus: 404}
+	ErrRateLimited = WebRPCError{Code: 1004, Name: "RateLimited", Message: "rate limit exceeded", HTTPStatus: 429}
+	ErrIdempotencyKeyReused = WebRPCError{Code: 1005, Name: "IdempotencyKeyReused", Message: "idempotency key was used for a different request", HTTPStatus: 422}
+	ErrIdempotencyKeyInProgress = WebRPCError{Code: 1006, Name: "IdempotencyKeyInProgress", Message: "request with the idempotency key is in progress", HTTPStatus: 409}
+	ErrMaintenance = WebRPCError{Code: 1007, Name: "Maintenance", Message: "service is under maintenance", HTTPStatus: 503}
+	ErrInvalidArgument = WebRPCError{Code: 1008, Name: "InvalidArgument", Message: "invalid argument", HTTPStatus: 400}
+	ErrConflict = WebRPCError{Code: 1009, Name: "Conflict", Message: "conflict with current state", HTTPStatus: 409}
+)
+
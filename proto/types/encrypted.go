@@ -0,0 +1,111 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang-cz/skeleton/pkg/keyring"
+)
+
+// Encrypted is a column type encrypting its value with the default keyring.
+// Values stored before the column got encrypted (plain bytes) are still
+// readable, so tables can be migrated in place.
+type Encrypted[T any] struct {
+	value T
+}
+
+func NewEncrypted[T any](value T) Encrypted[T] {
+	return Encrypted[T]{value: value}
+}
+
+// Get returns the decrypted value.
+func (e Encrypted[T]) Get() T {
+	return e.value
+}
+
+// Value encrypts the JSON encoded value and converts it to a driver.Value.
+func (e Encrypted[T]) Value() (driver.Value, error) {
+	b, err := json.Marshal(e.value)
+	if err != nil {
+		return nil, fmt.Errorf("marshal encrypted value: %w", err)
+	}
+
+	envelope, err := keyring.Encrypt(b)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt value: %w", err)
+	}
+
+	return envelope, nil
+}
+
+func (e *Encrypted[T]) Scan(src interface{}) error {
+	var zero T
+	e.value = zero
+
+	var b []byte
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf("invalid type for Encrypted: value is %T", src)
+	}
+
+	if !keyring.IsEnvelope(b) {
+		// Plaintext value stored before the column was encrypted.
+		if s, ok := any(&e.value).(*string); ok {
+			*s = string(b)
+			return nil
+		}
+		if err := json.Unmarshal(b, &e.value); err != nil {
+			return fmt.Errorf("unmarshal plaintext value: %w", err)
+		}
+		return nil
+	}
+
+	plaintext, err := keyring.Decrypt(b)
+	if err != nil {
+		return fmt.Errorf("decrypt value: %w", err)
+	}
+
+	if err := json.Unmarshal(plaintext, &e.value); err != nil {
+		return fmt.Errorf("unmarshal decrypted value: %w", err)
+	}
+
+	return nil
+}
+
+// BlindIndex is a keyed hash of a value allowing equality lookups
+// of Encrypted columns.
+type BlindIndex []byte
+
+func NewBlindIndex(value string) (BlindIndex, error) {
+	index, err := keyring.BlindIndex([]byte(value))
+	if err != nil {
+		return nil, fmt.Errorf("blind index: %w", err)
+	}
+	return index, nil
+}
+
+func (b BlindIndex) Value() (driver.Value, error) {
+	if b == nil {
+		return nil, nil
+	}
+	return []byte(b), nil
+}
+
+func (b *BlindIndex) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*b = nil
+	case []byte:
+		*b = append(BlindIndex(nil), v...)
+	default:
+		return fmt.Errorf("invalid type for BlindIndex: value is %T", src)
+	}
+	return nil
+}
@@ -1,12 +1,34 @@
 package proto
 
 import (
+	"time"
+
 	"github.com/gofrs/uuid/v5"
 )
 
 type User struct {
-	ID        uuid.UUID `db:"id,omitempty,pk" json:"id"`
-	Email     string    `db:"email"           json:"email"`
-	Firstname string    `db:"firstname"       json:"firstname"`
-	Lastname  string    `db:"lastname"        json:"lastname"`
+	ID        uuid.UUID  `db:"id,omitempty,pk" json:"id"`
+	Email     string     `db:"-"               json:"email"`
+	Firstname string     `db:"-"               json:"firstname"`
+	Lastname  string     `db:"-"               json:"lastname"`
+	CreatedAt time.Time  `db:"created_at"      json:"createdAt"`
+	UpdatedAt time.Time  `db:"updated_at"      json:"updatedAt"`
+	DeletedAt *time.Time `db:"deleted_at"      json:"deletedAt,omitempty"`
+}
+
+// UserInput holds writable fields of a user.
+type UserInput struct {
+	Email     string `json:"email"`
+	Firstname string `json:"firstname"`
+	Lastname  string `json:"lastname"`
+}
+
+// UsersFilter filters listed users. Names can't be filtered by, they're
+// encrypted; email matches exactly, case insensitive.
+type UsersFilter struct {
+	Email string `json:"email"`
+	// "active" (default), "deleted" or "all".
+	Status        string     `json:"status"`
+	CreatedAfter  *time.Time `json:"createdAfter,omitempty"`
+	CreatedBefore *time.Time `json:"createdBefore,omitempty"`
 }
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/golang-cz/skeleton/app/api/rest"
+)
+
+func TestAdmin(t *testing.T) {
+	t.Parallel()
+
+	adminURL := strings.TrimSuffix(E2E.URL, "/_api") + "/_admin"
+
+	call := func(t *testing.T, method, path, username, password string) (int, []byte) {
+		t.Helper()
+
+		req, err := http.NewRequest(method, adminURL+path, nil)
+		if err != nil {
+			t.Fatalf("create request: %v", err)
+		}
+		if username != "" {
+			req.SetBasicAuth(username, password)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("send request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("read response: %v", err)
+		}
+		return resp.StatusCode, body
+	}
+
+	username, password := E2E.Config.Admin.Username, E2E.Config.Admin.Password
+
+	t.Run("credentials", func(t *testing.T) {
+		if code, _ := call(t, "GET", "/build-info", "", ""); code != http.StatusUnauthorized {
+			t.Fatalf("unexpected status without credentials: %v", code)
+		}
+		if code, _ := call(t, "GET", "/build-info", username, "wrong"); code != http.StatusUnauthorized {
+			t.Fatalf("unexpected status with wrong password: %v", code)
+		}
+	})
+
+	t.Run("build info", func(t *testing.T) {
+		code, body := call(t, "GET", "/build-info", username, password)
+		if code != http.StatusOK || !strings.HasPrefix(string(body), "version\t") {
+			t.Fatalf("unexpected response: %v %s", code, body)
+		}
+	})
+
+	t.Run("config", func(t *testing.T) {
+		code, body := call(t, "GET", "/config", username, password)
+		if code != http.StatusOK {
+			t.Fatalf("unexpected status: %v", code)
+		}
+
+		var conf struct {
+			Admin struct {
+				Username string `json:"username"`
+				Password string `json:"password"`
+			} `json:"admin"`
+			Auth struct {
+				HS256Secret string `json:"hs256_secret"`
+			} `json:"auth"`
+		}
+		if err := json.Unmarshal(body, &conf); err != nil {
+			t.Fatalf("decode config: %v", err)
+		}
+		if conf.Admin.Username != username {
+			t.Fatalf("unexpected admin username: %q", conf.Admin.Username)
+		}
+		if conf.Admin.Password != "[redacted]" || conf.Auth.HS256Secret != "[redacted]" {
+			t.Fatalf("secrets are not redacted: %s", body)
+		}
+		if strings.Contains(string(body), password) {
+			t.Fatalf("config dump contains admin password")
+		}
+	})
+
+	t.Run("pprof", func(t *testing.T) {
+		for _, profile := range []string{"goroutine", "allocs", "block", "mutex"} {
+			if code, _ := call(t, "GET", "/debug/pprof/"+profile+"?debug=1", username, password); code != http.StatusOK {
+				t.Fatalf("unexpected status of %v profile: %v", profile, code)
+			}
+		}
+	})
+
+	t.Run("log level", func(t *testing.T) {
+		code, body := call(t, "GET", "/log-level", username, password)
+		if code != http.StatusOK {
+			t.Fatalf("unexpected status: %v", code)
+		}
+		var level struct {
+			Level string `json:"level"`
+		}
+		if err := json.Unmarshal(body, &level); err != nil {
+			t.Fatalf("decode level: %v", err)
+		}
+		defer call(t, "PUT", "/log-level?level="+level.Level, username, password)
+
+		code, body = call(t, "PUT", "/log-level?level=warn", username, password)
+		if code != http.StatusOK || !strings.Contains(string(body), `"WARN"`) {
+			t.Fatalf("unexpected response: %v %s", code, body)
+		}
+
+		if code, _ := call(t, "PUT", "/log-level?level=loud", username, password); code != http.StatusBadRequest {
+			t.Fatalf("unexpected status of invalid level: %v", code)
+		}
+	})
+
+	t.Run("spoofed IP", func(t *testing.T) {
+		conf := *E2E.Config
+		conf.Admin.AllowedIPs = []string{"10.0.0.1"}
+		srv := &rest.Server{Config: &conf}
+		adminRouter, err := srv.AdminRouter()
+		if err != nil {
+			t.Fatalf("admin router: %v", err)
+		}
+
+		r := chi.NewRouter()
+		r.Use(srv.PeerAddr)
+		r.Use(middleware.RealIP)
+		r.Mount("/_admin", adminRouter)
+
+		req := httptest.NewRequest("GET", "/_admin/build-info", nil)
+		req.RemoteAddr = "192.0.2.1:4242"
+		req.Header.Set("X-Forwarded-For", "10.0.0.1")
+		req.SetBasicAuth(username, password)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Fatalf("unexpected status with spoofed X-Forwarded-For: %v", w.Code)
+		}
+	})
+
+	t.Run("not public", func(t *testing.T) {
+		for _, path := range []string{"/debug/pprof/", "/sentry", "/_api/status", "/metrics"} {
+			resp, err := http.Get(strings.TrimSuffix(E2E.URL, "/_api") + path)
+			if err != nil {
+				t.Fatalf("get %v: %v", path, err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != http.StatusNotFound {
+				t.Fatalf("unexpected status of %v: %v", path, resp.StatusCode)
+			}
+		}
+	})
+}
@@ -0,0 +1,236 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-cz/skeleton/app/api/rpc"
+	"github.com/golang-cz/skeleton/proto"
+	"github.com/golang-cz/skeleton/proto/client/skeleton"
+)
+
+func TestApiKey(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	apiKey, key, err := E2E.RPCClient.CreateApiKey(ctx, "e2e reader", []string{rpc.ScopeUsersRead}, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("create api key: %v", err)
+	}
+	if !strings.HasPrefix(key, "skel_"+apiKey.Prefix+"_") {
+		t.Fatalf("unexpected key format: %q", key)
+	}
+
+	client := E2E.RPCClientWithApiKey(key)
+
+	user, err := client.GetUser(ctx, E2E.UserId.String())
+	if err != nil {
+		t.Fatalf("get user with api key: %v", err)
+	}
+	if user.ID != E2E.UserId {
+		t.Fatalf("unexpected user: got %v, want %v", user.ID, E2E.UserId)
+	}
+
+	// API keys can't manage API keys.
+	_, err = client.ListApiKeys(ctx)
+	assertRPCError(t, err, proto.ErrPermissionDenied.Code)
+
+	apiKeys, err := E2E.RPCClient.ListApiKeys(ctx)
+	if err != nil {
+		t.Fatalf("list api keys: %v", err)
+	}
+	var listed *skeleton.ApiKey
+	for _, k := range apiKeys {
+		if k.ID == apiKey.ID {
+			listed = k
+		}
+	}
+	if listed == nil {
+		t.Fatalf("api key %v not listed", apiKey.ID)
+	}
+	if listed.LastUsedAt == nil {
+		t.Fatalf("api key usage not tracked")
+	}
+
+	if err := E2E.RPCClient.RevokeApiKey(ctx, apiKey.ID.String()); err != nil {
+		t.Fatalf("revoke api key: %v", err)
+	}
+
+	_, err = client.GetUser(ctx, E2E.UserId.String())
+	assertRPCError(t, err, proto.ErrUnauthenticated.Code)
+
+	err = E2E.RPCClient.RevokeApiKey(ctx, apiKey.ID.String())
+	assertRPCError(t, err, proto.ErrNotFound.Code)
+}
+
+func TestApiKeyScopes(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	_, key, err := E2E.RPCClient.CreateApiKey(ctx, "e2e no scopes", nil, time.Time{})
+	if err != nil {
+		t.Fatalf("create api key: %v", err)
+	}
+
+	_, err = E2E.RPCClientWithApiKey(key).GetUser(ctx, E2E.UserId.String())
+	assertRPCError(t, err, proto.ErrPermissionDenied.Code)
+
+	_, _, err = E2E.RPCClient.CreateApiKey(ctx, "e2e unknown scope", []string{"users:write-everything"}, time.Time{})
+	assertRPCError(t, err, proto.ErrInvalidArgument.Code)
+}
+
+func TestApiKeyOwnership(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	apiKey, _, err := E2E.RPCClient.CreateApiKey(ctx, "e2e owned", nil, time.Time{})
+	if err != nil {
+		t.Fatalf("create api key: %v", err)
+	}
+
+	other, err := E2E.RPCClient.CreateUser(ctx, newUserInput("Geezer", "Butler"))
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if err := E2E.RPCClient.AssignRole(ctx, other.ID.String(), "editor"); err != nil {
+		t.Fatalf("assign role: %v", err)
+	}
+	client := rbacClient(t, other.ID, E2E.ApplicationId)
+
+	apiKeys, err := client.ListApiKeys(ctx)
+	if err != nil {
+		t.Fatalf("list api keys: %v", err)
+	}
+	if len(apiKeys) != 0 {
+		t.Fatalf("keys of other users listed: %v", apiKeys)
+	}
+
+	err = client.RevokeApiKey(ctx, apiKey.ID.String())
+	assertRPCError(t, err, proto.ErrNotFound.Code)
+
+	if err := E2E.RPCClient.RevokeApiKey(ctx, apiKey.ID.String()); err != nil {
+		t.Fatalf("revoke own api key: %v", err)
+	}
+}
+
+// TestApiKeyEscalation checks users can't create API keys doing more than
+// their roles allow.
+func TestApiKeyEscalation(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	user, err := E2E.RPCClient.CreateUser(ctx, newUserInput("Bill", "Ward"))
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	target, err := E2E.RPCClient.CreateUser(ctx, newUserInput("Tony", "Martin"))
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	client := rbacClient(t, user.ID, E2E.ApplicationId)
+
+	// Users without roles can't manage API keys.
+	_, _, err = client.CreateApiKey(ctx, "e2e no roles", []string{rpc.ScopeUsersWrite}, time.Time{})
+	assertRPCError(t, err, proto.ErrPermissionDenied.Code)
+
+	if err := E2E.RPCClient.AssignRole(ctx, user.ID.String(), "editor"); err != nil {
+		t.Fatalf("assign role: %v", err)
+	}
+
+	// Editors can't delete users, neither can their keys.
+	_, _, err = client.CreateApiKey(ctx, "e2e editor deleter", []string{rpc.ScopeUsersDelete}, time.Time{})
+	assertRPCError(t, err, proto.ErrPermissionDenied.Code)
+
+	_, key, err := client.CreateApiKey(ctx, "e2e editor writer", []string{rpc.ScopeUsersWrite}, time.Time{})
+	if err != nil {
+		t.Fatalf("create api key: %v", err)
+	}
+	err = E2E.RPCClientWithApiKey(key).DeleteUser(ctx, target.ID.String())
+	assertRPCError(t, err, proto.ErrPermissionDenied.Code)
+
+	_, key, err = E2E.RPCClient.CreateApiKey(ctx, "e2e admin deleter", []string{rpc.ScopeUsersDelete}, time.Time{})
+	if err != nil {
+		t.Fatalf("create api key: %v", err)
+	}
+	if err := E2E.RPCClientWithApiKey(key).DeleteUser(ctx, target.ID.String()); err != nil {
+		t.Fatalf("delete user with api key: %v", err)
+	}
+}
+
+// TestApiKeyCreatorDeleted checks API keys stop working once their creator
+// is deleted.
+func TestApiKeyCreatorDeleted(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	user, err := E2E.RPCClient.CreateUser(ctx, newUserInput("Glenn", "Hughes"))
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if err := E2E.RPCClient.AssignRole(ctx, user.ID.String(), "editor"); err != nil {
+		t.Fatalf("assign role: %v", err)
+	}
+
+	_, key, err := rbacClient(t, user.ID, E2E.ApplicationId).CreateApiKey(ctx, "e2e creator deleted", []string{rpc.ScopeUsersRead}, time.Time{})
+	if err != nil {
+		t.Fatalf("create api key: %v", err)
+	}
+	if _, err := E2E.RPCClientWithApiKey(key).GetUser(ctx, E2E.UserId.String()); err != nil {
+		t.Fatalf("get user with api key: %v", err)
+	}
+
+	if err := E2E.RPCClient.DeleteUser(ctx, user.ID.String()); err != nil {
+		t.Fatalf("delete user: %v", err)
+	}
+
+	_, err = E2E.RPCClientWithApiKey(key).GetUser(ctx, E2E.UserId.String())
+	assertRPCError(t, err, proto.ErrPermissionDenied.Code)
+}
+
+// TestApiKeyCreatorRoleUnassigned checks API keys lose scopes their creator
+// lost the permissions for.
+func TestApiKeyCreatorRoleUnassigned(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	user, err := E2E.RPCClient.CreateUser(ctx, newUserInput("David", "Coverdale"))
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if err := E2E.RPCClient.AssignRole(ctx, user.ID.String(), "editor"); err != nil {
+		t.Fatalf("assign role: %v", err)
+	}
+
+	_, key, err := rbacClient(t, user.ID, E2E.ApplicationId).CreateApiKey(ctx, "e2e creator unassigned", []string{rpc.ScopeUsersRead}, time.Time{})
+	if err != nil {
+		t.Fatalf("create api key: %v", err)
+	}
+	if _, err := E2E.RPCClientWithApiKey(key).GetUser(ctx, E2E.UserId.String()); err != nil {
+		t.Fatalf("get user with api key: %v", err)
+	}
+
+	if err := E2E.RPCClient.UnassignRole(ctx, user.ID.String(), "editor"); err != nil {
+		t.Fatalf("unassign role: %v", err)
+	}
+
+	_, err = E2E.RPCClientWithApiKey(key).GetUser(ctx, E2E.UserId.String())
+	assertRPCError(t, err, proto.ErrPermissionDenied.Code)
+}
+
+func assertRPCError(t *testing.T, err error, code int) {
+	t.Helper()
+
+	var rpcErr skeleton.WebRPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != code {
+		t.Fatalf("unexpected error: got %v, want code %v", err, code)
+	}
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang-cz/skeleton/data"
+	"github.com/golang-cz/skeleton/internal/guuid"
+	"github.com/golang-cz/skeleton/proto"
+	"github.com/golang-cz/skeleton/proto/client/skeleton"
+)
+
+func TestAuth(t *testing.T) {
+	t.Parallel()
+
+	expired, err := E2E.Token(E2E.UserId, -time.Minute)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+
+	// Token of a deleted user.
+	deletedId := guuid.NewV7()
+	deletedAt := time.Now()
+	deleted := &data.User{
+		User: &proto.User{
+			ID:        deletedId,
+			Email:     fmt.Sprintf("deleted+%s@golang.cz", deletedId),
+			Firstname: "Deleted",
+			Lastname:  "User",
+			DeletedAt: &deletedAt,
+		},
+	}
+	if err := E2E.DB.Save(deleted); err != nil {
+		t.Fatalf("save user to DB: %v", err)
+	}
+	deletedToken, err := E2E.Token(deletedId, time.Hour)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+
+	tt := []struct {
+		name  string
+		token string
+		code  int
+	}{
+		{name: "no token", token: "", code: proto.ErrUnauthenticated.Code},
+		{name: "malformed token", token: "not-a-jwt", code: proto.ErrUnauthenticated.Code},
+		{name: "expired token", token: expired, code: proto.ErrUnauthenticated.Code},
+		{name: "deleted user", token: deletedToken, code: proto.ErrPermissionDenied.Code},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := E2E.RPCClientWithToken(tc.token).GetUser(context.Background(), E2E.UserId.String())
+
+			var rpcErr skeleton.WebRPCError
+			if !errors.As(err, &rpcErr) || rpcErr.Code != tc.code {
+				t.Fatalf("unexpected error: got %v, want code %v", err, tc.code)
+			}
+		})
+	}
+}
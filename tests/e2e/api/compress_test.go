@@ -0,0 +1,101 @@
+package api
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestCompression(t *testing.T) {
+	t.Parallel()
+
+	root := strings.TrimSuffix(E2E.URL, "/_api")
+
+	get := func(t *testing.T, path, acceptEncoding string) (*http.Response, []byte) {
+		t.Helper()
+
+		req, err := http.NewRequest("GET", root+path, nil)
+		if err != nil {
+			t.Fatalf("create request: %v", err)
+		}
+		req.SetBasicAuth(E2E.Config.Admin.Username, E2E.Config.Admin.Password)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("send request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		var body io.Reader = resp.Body
+		switch resp.Header.Get("Content-Encoding") {
+		case "gzip":
+			body, err = gzip.NewReader(resp.Body)
+		case "br":
+			body = brotli.NewReader(resp.Body)
+		case "zstd":
+			var dec *zstd.Decoder
+			dec, err = zstd.NewReader(resp.Body)
+			if err == nil {
+				defer dec.Close()
+			}
+			body = dec
+		}
+		if err != nil {
+			t.Fatalf("create decoder: %v", err)
+		}
+
+		b, err := io.ReadAll(body)
+		if err != nil {
+			t.Fatalf("read response: %v", err)
+		}
+		return resp, b
+	}
+
+	_, plain := get(t, "/_admin/status", "identity")
+	if len(plain) < E2E.Config.HTTP.Compression.MinSize {
+		t.Fatalf("status page is too small to be compressed: %v bytes", len(plain))
+	}
+
+	for acceptEncoding, want := range map[string]string{
+		"gzip":                 "gzip",
+		"br":                   "br",
+		"zstd":                 "zstd",
+		"gzip, deflate, br":    "br",
+		"zstd;q=0, gzip;q=0.5": "gzip",
+		"*":                    "zstd",
+		"identity":             "",
+		"deflate":              "",
+	} {
+		resp, body := get(t, "/_admin/status", acceptEncoding)
+		if got := resp.Header.Get("Content-Encoding"); got != want {
+			t.Errorf("Accept-Encoding %q: got encoding %q, want %q", acceptEncoding, got, want)
+		}
+		if resp.Header.Get("Vary") != "Accept-Encoding" {
+			t.Errorf("Accept-Encoding %q: unexpected Vary %q", acceptEncoding, resp.Header.Get("Vary"))
+		}
+		if !strings.Contains(string(body), "</html>") {
+			t.Errorf("Accept-Encoding %q: unexpected body %.100q", acceptEncoding, body)
+		}
+	}
+
+	t.Run("small response", func(t *testing.T) {
+		resp, _ := get(t, "/favicon.ico", "gzip")
+		if enc := resp.Header.Get("Content-Encoding"); enc != "" {
+			t.Fatalf("small response is encoded with %v", enc)
+		}
+	})
+
+	t.Run("already compressed", func(t *testing.T) {
+		// Heap profile is gzipped protobuf.
+		resp, _ := get(t, "/_admin/debug/pprof/heap", "gzip")
+		if enc := resp.Header.Get("Content-Encoding"); enc != "" {
+			t.Fatalf("compressed profile is encoded with %v", enc)
+		}
+	})
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/upper/db/v4"
+
+	"github.com/golang-cz/skeleton/app/api/rpc"
+	"github.com/golang-cz/skeleton/data"
+	"github.com/golang-cz/skeleton/internal/guuid"
+	"github.com/golang-cz/skeleton/proto"
+	"github.com/golang-cz/skeleton/proto/client/skeleton"
+)
+
+func TestErrorCatalogue(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	existing, err := E2E.RPCClient.CreateUser(ctx, newUserInput("Robert", "Plant"))
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	tt := []struct {
+		name string
+		call func() error
+		want skeleton.WebRPCError
+	}{
+		{
+			name: "not found",
+			call: func() error {
+				_, err := E2E.RPCClient.GetUser(ctx, guuid.NewV7().String())
+				return err
+			},
+			want: skeleton.ErrNotFound,
+		},
+		{
+			name: "malformed id",
+			call: func() error {
+				_, err := E2E.RPCClient.GetUser(ctx, "42")
+				return err
+			},
+			want: skeleton.ErrInvalidArgument,
+		},
+		{
+			name: "unique violation",
+			call: func() error {
+				input := newUserInput("Robert", "Plant")
+				input.Email = existing.Email
+				_, err := E2E.RPCClient.CreateUser(ctx, input)
+				return err
+			},
+			want: skeleton.ErrConflict,
+		},
+		{
+			name: "foreign key violation",
+			call: func() error {
+				return E2E.RPCClient.AssignRole(ctx, guuid.NewV7().String(), "viewer")
+			},
+			want: skeleton.ErrInvalidArgument,
+		},
+		{
+			name: "invalid API key",
+			call: func() error {
+				_, err := E2E.RPCClientWithApiKey("skel_00000000_secret").GetUser(ctx, existing.ID.String())
+				return err
+			},
+			want: skeleton.ErrUnauthenticated,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.call()
+
+			var rpcErr skeleton.WebRPCError
+			if !errors.As(err, &rpcErr) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rpcErr.Code != tc.want.Code || rpcErr.Name != tc.want.Name || rpcErr.HTTPStatus != tc.want.HTTPStatus {
+				t.Fatalf("unexpected error: got %v (status %v), want %v (status %v)", rpcErr, rpcErr.HTTPStatus, tc.want, tc.want.HTTPStatus)
+			}
+		})
+	}
+}
+
+func TestMapError(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		name string
+		err  error
+		want proto.WebRPCError
+	}{
+		{name: "no more rows", err: fmt.Errorf("get user: %w", db.ErrNoMoreRows), want: proto.ErrNotFound},
+		{name: "no rows", err: fmt.Errorf("get user: %w", sql.ErrNoRows), want: proto.ErrNotFound},
+		{name: "unique violation", err: fmt.Errorf("save: %w", &pgconn.PgError{Code: "23505"}), want: proto.ErrConflict},
+		{name: "email taken", err: fmt.Errorf("save: %w", data.ErrEmailTaken), want: proto.ErrConflict},
+		{name: "foreign key violation", err: fmt.Errorf("save: %w", &pgconn.PgError{Code: "23503"}), want: proto.ErrInvalidArgument},
+		{name: "validation", err: fmt.Errorf("save: %w", data.Invalidf("name is required")), want: proto.ErrInvalidArgument},
+		{name: "invalid API key", err: data.ErrInvalidApiKey, want: proto.ErrUnauthenticated},
+		{name: "other", err: context.DeadlineExceeded, want: proto.ErrWebrpcInternalError},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rpcErr := proto.ErrWebrpcEndpoint.WithCause(tc.err)
+			rpc.MapError(&rpcErr)
+
+			if rpcErr.Code != tc.want.Code || rpcErr.HTTPStatus != tc.want.HTTPStatus {
+				t.Fatalf("unexpected error: got %v (status %v), want %v (status %v)", rpcErr, rpcErr.HTTPStatus, tc.want, tc.want.HTTPStatus)
+			}
+			if !errors.Is(rpcErr, tc.err) {
+				t.Fatalf("cause is lost: %v", rpcErr)
+			}
+		})
+	}
+
+	t.Run("catalogued", func(t *testing.T) {
+		rpcErr := proto.ErrPermissionDenied.WithCause(errors.New("missing users:read"))
+		rpc.MapError(&rpcErr)
+
+		if rpcErr.Code != proto.ErrPermissionDenied.Code {
+			t.Fatalf("catalogued error was mapped: %v", rpcErr)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-cz/skeleton/app/api/rest"
+)
+
+func TestLivez(t *testing.T) {
+	t.Parallel()
+
+	resp, err := http.Get(E2E.URL + "/livez")
+	if err != nil {
+		t.Fatalf("get livez: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status: %v", resp.StatusCode)
+	}
+}
+
+// TestReadyz checks readiness of a dedicated server, so draining it doesn't
+// affect other tests.
+func TestReadyz(t *testing.T) {
+	t.Parallel()
+
+	srv := &rest.Server{Config: E2E.Config, DB: E2E.DB}
+	ts := httptest.NewServer(srv.Health(http.NotFoundHandler()))
+	defer ts.Close()
+
+	type readiness struct {
+		Ready    bool `json:"ready"`
+		Draining bool `json:"draining"`
+		Probes   []struct {
+			Key    string `json:"key"`
+			Status string `json:"status"`
+		} `json:"probes"`
+	}
+
+	get := func(t *testing.T) (int, readiness) {
+		t.Helper()
+
+		resp, err := http.Get(ts.URL + "/_api/readyz")
+		if err != nil {
+			t.Fatalf("get readyz: %v", err)
+		}
+		defer resp.Body.Close()
+
+		var body readiness
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("decode readyz: %v", err)
+		}
+		return resp.StatusCode, body
+	}
+
+	code, body := get(t)
+	probes := map[string]string{}
+	for _, p := range body.Probes {
+		probes[p.Key] = p.Status
+	}
+	if probes["SkeletonDb"] != "healthy" || probes["SkeletonDbMigrations"] != "healthy" {
+		t.Fatalf("unexpected DB probes: %+v", body.Probes)
+	}
+	if _, ok := probes["NATS"]; !ok {
+		t.Fatalf("NATS probe is missing: %+v", body.Probes)
+	}
+	if wantCode := map[bool]int{true: http.StatusOK, false: http.StatusServiceUnavailable}[body.Ready]; code != wantCode {
+		t.Fatalf("unexpected status: got %v, want %v for %+v", code, wantCode, body)
+	}
+
+	srv.Drain()
+
+	code, body = get(t)
+	if code != http.StatusServiceUnavailable || body.Ready || !body.Draining {
+		t.Fatalf("unexpected readiness while draining: %v %+v", code, body)
+	}
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-cz/skeleton/internal/guuid"
+	"github.com/golang-cz/skeleton/proto"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	t.Parallel()
+
+	key := guuid.NewV7().String()
+
+	call := func(t *testing.T, client *http.Client, body string) (*http.Response, []byte) {
+		t.Helper()
+
+		req, err := http.NewRequest("POST", E2E.URL+"/rpc/Skeleton/CreateApiKey", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("create request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", key)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("send request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("read response: %v", err)
+		}
+
+		return resp, respBody
+	}
+
+	body := `{"name": "e2e idempotent", "scopes": [], "expiresAt": "0001-01-01T00:00:00Z"}`
+
+	first, firstBody := call(t, E2E.Client, body)
+	if first.StatusCode != http.StatusOK || first.Header.Get("Idempotent-Replayed") != "" {
+		t.Fatalf("unexpected first response: %v %s", first.StatusCode, firstBody)
+	}
+
+	retry, retryBody := call(t, E2E.Client, body)
+	if retry.StatusCode != http.StatusOK || retry.Header.Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("unexpected retry response: %v %s", retry.StatusCode, retryBody)
+	}
+	if string(retryBody) != string(firstBody) {
+		t.Fatalf("retry got different response: got %s, want %s", retryBody, firstBody)
+	}
+
+	// Only one key was created.
+	count, err := E2E.DB.ApiKey.Find("name", "e2e idempotent").Count()
+	if err != nil {
+		t.Fatalf("count api keys: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("unexpected number of api keys: got %v, want 1", count)
+	}
+
+	reused, reusedBody := call(t, E2E.Client, `{"name": "e2e different", "scopes": [], "expiresAt": "0001-01-01T00:00:00Z"}`)
+	if reused.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("unexpected response to reused key: %v %s", reused.StatusCode, reusedBody)
+	}
+
+	var rpcErr proto.WebRPCError
+	if err := json.Unmarshal(reusedBody, &rpcErr); err != nil || rpcErr.Code != proto.ErrIdempotencyKeyReused.Code {
+		t.Fatalf("unexpected error: %s", reusedBody)
+	}
+
+	// Keys are scoped to the application of the token too.
+	otherAppId := guuid.NewV7()
+	if err := E2E.DB.Role.Assign(E2E.UserId, otherAppId, "admin"); err != nil {
+		t.Fatalf("assign role: %v", err)
+	}
+	token, err := E2E.ApplicationToken(E2E.UserId, otherAppId, time.Hour)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	otherApp := &http.Client{Transport: &authTransport{authorization: "Bearer " + token}}
+
+	other, otherBody := call(t, otherApp, body)
+	if other.StatusCode != http.StatusOK || other.Header.Get("Idempotent-Replayed") != "" {
+		t.Fatalf("unexpected response in other application: %v %s", other.StatusCode, otherBody)
+	}
+}
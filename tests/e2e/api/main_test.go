@@ -2,7 +2,7 @@ package api
 
 import (
 	"context"
-	"errors"
+	"database/sql"
 	"fmt"
 	"log"
 	"log/slog"
@@ -15,18 +15,25 @@ import (
 	"time"
 
 	"github.com/gofrs/uuid/v5"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/goware/urlx"
+	"github.com/lib/pq"
 
 	"github.com/golang-cz/skeleton/app/api"
 	"github.com/golang-cz/skeleton/config"
 	"github.com/golang-cz/skeleton/data"
+	"github.com/golang-cz/skeleton/data/migration"
 	"github.com/golang-cz/skeleton/internal/core"
+	"github.com/golang-cz/skeleton/internal/guuid"
+	"github.com/golang-cz/skeleton/pkg/jwtauth"
 	"github.com/golang-cz/skeleton/pkg/version"
+	"github.com/golang-cz/skeleton/proto"
 	"github.com/golang-cz/skeleton/proto/client/skeleton"
 )
 
 type E2EServices struct {
 	ProjectRootDirectory string
+	URL                  string
 	User                 *data.User
 	API                  *api.API
 	DB                   *data.Database
@@ -34,11 +41,16 @@ type E2EServices struct {
 	Client               *http.Client
 	RPCClient            skeleton.Skeleton
 	UserId               uuid.UUID
+	ApplicationId        uuid.UUID
 }
 
 var E2E *E2EServices
 
 func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
 	E2E = &E2EServices{}
 
 	// Get the current Go module path.
@@ -55,36 +67,78 @@ func TestMain(m *testing.M) {
 	}
 	E2E.Config = conf // Adding configuration to E2E
 
+	// Tests don't run from the project root.
+	conf.Encryption.Keyring = filepath.Join(E2E.ProjectRootDirectory, conf.Encryption.Keyring)
+
 	// Setup application
 	err = core.SetupApp(conf, "SKELETON-E2E", version.VERSION)
 	if err != nil {
 		log.Fatalf("setting up app: %v", err)
 	}
 
-	err = initDB(conf.DB.Database)
+	// Every test package gets its own database cloned from a migrated template,
+	// so packages can't see each other's data.
+	database, err := initDB(conf, "api")
 	if err != nil {
 		log.Fatalf("starting DB: %v", err)
 	}
+	defer func() {
+		if err := dropDB(conf, database); err != nil {
+			slog.Error("failed to drop test database", "database", database, "err", err)
+		}
+	}()
+	conf.DB.Database = database
 
 	// Create app & connect to DB, NATS etc.
 	app, err := api.New(context.Background(), conf)
 	if err != nil {
-		log.Fatalf("creating API: %v", err)
+		slog.Error("creating API", "err", err)
+		return 1
 	}
 	defer app.Stop(time.Second)
 
+	E2E.API = app
 	E2E.DB = app.DB
 
 	internalUrl, _ := urlx.Parse(fmt.Sprintf("http://localhost%s/_api", conf.Port))
 
-	E2E.Client = &http.Client{Timeout: 10 * time.Second}
+	// Requests are authenticated as E2E.User, admin of E2E.ApplicationId.
+	E2E.ApplicationId = guuid.NewV7()
+	E2E.UserId = guuid.NewV7()
+	E2E.User = &data.User{
+		User: &proto.User{
+			ID:        E2E.UserId,
+			Email:     fmt.Sprintf("e2e+%s@golang.cz", E2E.UserId),
+			Firstname: "E2E",
+			Lastname:  "Tester",
+		},
+	}
+	if err := E2E.DB.Save(E2E.User); err != nil {
+		slog.Error("creating E2E user", "err", err)
+		return 1
+	}
+	if err := E2E.DB.Role.Assign(E2E.UserId, E2E.ApplicationId, "admin"); err != nil {
+		slog.Error("assigning role to E2E user", "err", err)
+		return 1
+	}
+
+	token, err := E2E.Token(E2E.UserId, time.Hour)
+	if err != nil {
+		slog.Error("signing E2E token", "err", err)
+		return 1
+	}
 
-	E2E.RPCClient = skeleton.NewSkeletonClient(internalUrl.String(), E2E.Client)
+	E2E.URL = internalUrl.String()
+	E2E.Client = &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &authTransport{authorization: "Bearer " + token},
+	}
 
+	E2E.RPCClient = skeleton.NewSkeletonClient(E2E.URL, E2E.Client)
+
+	runErr := make(chan error, 1)
 	go func() {
-		if err := app.Run(); err != nil {
-			log.Fatalf("failed to run API: %v", err)
-		}
+		runErr <- app.Run()
 	}()
 
 	// check if http server is ready via health endpoint
@@ -112,40 +166,180 @@ func TestMain(m *testing.M) {
 		if serverReady {
 			break
 		}
+
+		select {
+		case err := <-runErr:
+			slog.Error("running API", "err", err)
+			return 1
+		default:
+		}
 	}
 
 	// Run tests.
-	os.Exit(m.Run())
+	return m.Run()
 }
 
-func initDB(database string) error {
-	slog.Debug("Initializing DB", "database", database)
+// TxDB returns database with all stores bound to a transaction, which is
+// rolled back once the test finishes. Use it for tests working directly with
+// the data layer; the API server doesn't see uncommitted data.
+func (e *E2EServices) TxDB(t *testing.T) *data.Database {
+	t.Helper()
 
-	// Execute the command to create the new schema
-	createCmd := exec.Command("bash", "-c", fmt.Sprintf(`docker exec postgres /bin/sh -c '/home/db.sh create %s'`, database))
-	_, err := createCmd.Output()
+	sqlTx, err := e.DB.Driver().(*sql.DB).BeginTx(context.Background(), nil)
 	if err != nil {
-		if e, ok := err.(*exec.ExitError); ok {
-			slog.Error("Failed to create schema", "schema", database, "err", err)
-			return fmt.Errorf("failed to create schema %q: %w", database, errors.New(string(e.Stderr)))
-		}
-		slog.Error("Failed to run db.sh script", "err", err)
-		return fmt.Errorf("failed to run db.sh script: %w", err)
+		t.Fatalf("begin transaction: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = sqlTx.Rollback()
+	})
+
+	tx, err := data.NewTx(sqlTx)
+	if err != nil {
+		t.Fatalf("wrap transaction: %v", err)
+	}
+
+	return tx
+}
+
+// Token signs HS256 token for given user in E2E.ApplicationId, same as the
+// auth service would.
+func (e *E2EServices) Token(userId uuid.UUID, ttl time.Duration) (string, error) {
+	return e.ApplicationToken(userId, e.ApplicationId, ttl)
+}
+
+// ApplicationToken signs HS256 token for given user in given application,
+// uuid.Nil leaves the app_id claim out.
+func (e *E2EServices) ApplicationToken(userId, applicationId uuid.UUID, ttl time.Duration) (string, error) {
+	claims := jwtauth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    e.Config.Auth.Issuer,
+			Audience:  jwt.ClaimStrings{e.Config.Auth.Audience},
+			Subject:   userId.String(),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+		},
+	}
+	if !applicationId.IsNil() {
+		claims.ApplicationId = applicationId.String()
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(e.Config.Auth.HS256Secret))
+}
+
+// RPCClientWithToken returns RPC client sending given Authorization bearer
+// token, or no Authorization header if the token is empty.
+func (e *E2EServices) RPCClientWithToken(token string) skeleton.Skeleton {
+	if token == "" {
+		return e.rpcClient("")
+	}
+	return e.rpcClient("Bearer " + token)
+}
+
+// RPCClientWithApiKey returns RPC client authenticated by given API key.
+func (e *E2EServices) RPCClientWithApiKey(key string) skeleton.Skeleton {
+	return e.rpcClient("ApiKey " + key)
+}
+
+func (e *E2EServices) rpcClient(authorization string) skeleton.Skeleton {
+	client := &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &authTransport{authorization: authorization},
 	}
+	return skeleton.NewSkeletonClient(e.URL, client)
+}
+
+type authTransport struct {
+	authorization string
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.authorization != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", t.authorization)
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// templateLockId is a Postgres advisory lock key serializing the template
+// database setup across test packages running in parallel.
+const templateLockId = 7081_0001
+
+// initDB migrates the template database and creates a new database for given
+// test package from it. It returns name of the created database.
+func initDB(conf *config.Config, pkg string) (string, error) {
+	template := conf.DB.Database + "_template"
+	database := fmt.Sprintf("%s_%s_%s", conf.DB.Database, pkg, strings.ReplaceAll(guuid.NewV7().String(), "-", "")[20:])
+
+	slog.Debug("Initializing DB", "database", database, "template", template)
 
-	// Import the schema.sql script into the new schema
-	importCmd := exec.Command("bash", "-c", fmt.Sprintf(`docker exec postgres /bin/sh -c '/home/db.sh import %s %s'`, database, "/home/schema.sql"))
-	_, err = importCmd.Output()
+	ctx := context.Background()
+
+	maintenance, err := connect(conf, "postgres")
 	if err != nil {
-		if e, ok := err.(*exec.ExitError); ok {
-			slog.Error("Failed to import schema.sql for", "schema", database, "err", err)
-			return fmt.Errorf("failed to import schema.sql for %q: %w", database, errors.New(string(e.Stderr)))
+		return "", err
+	}
+	defer maintenance.Close()
+
+	conn, err := maintenance.Driver().(*sql.DB).Conn(ctx)
+	if err != nil {
+		return "", fmt.Errorf("get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, templateLockId); err != nil {
+		return "", fmt.Errorf("lock template database: %w", err)
+	}
+	defer conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, templateLockId)
+
+	// Recreate the template, so it always reflects the current migrations.
+	for _, query := range []string{
+		fmt.Sprintf(`DROP DATABASE IF EXISTS %s WITH (FORCE)`, pq.QuoteIdentifier(template)),
+		fmt.Sprintf(`CREATE DATABASE %s`, pq.QuoteIdentifier(template)),
+	} {
+		if _, err := conn.ExecContext(ctx, query); err != nil {
+			return "", fmt.Errorf("recreate template database %q: %w", template, err)
 		}
-		slog.Error("Failed to run db.sh script", "err", err)
-		return fmt.Errorf("failed to run db.sh script: %w", err)
 	}
 
-	slog.Info("Schema created and schema.sql imported", "schema", database)
+	templateConf := *conf
+	templateConf.DB.Database = template
+	if err := migration.RunMigrations([]string{"up"}, &templateConf); err != nil {
+		return "", fmt.Errorf("migrate template database %q: %w", template, err)
+	}
+
+	_, err = conn.ExecContext(ctx, fmt.Sprintf(`CREATE DATABASE %s TEMPLATE %s`, pq.QuoteIdentifier(database), pq.QuoteIdentifier(template)))
+	if err != nil {
+		return "", fmt.Errorf("create database %q from template: %w", database, err)
+	}
+
+	slog.Info("Database created from migrated template", "database", database, "template", template)
+
+	return database, nil
+}
+
+func dropDB(conf *config.Config, database string) error {
+	maintenance, err := connect(conf, "postgres")
+	if err != nil {
+		return err
+	}
+	defer maintenance.Close()
+
+	_, err = maintenance.SQL().Exec(fmt.Sprintf(`DROP DATABASE IF EXISTS %s WITH (FORCE)`, pq.QuoteIdentifier(database)))
+	if err != nil {
+		return fmt.Errorf("drop database %q: %w", database, err)
+	}
 
 	return nil
 }
+
+func connect(conf *config.Config, database string) (*data.Database, error) {
+	dbConf := conf.DB
+	dbConf.Database = database
+
+	sess, err := data.NewDBSession(dbConf)
+	if err != nil {
+		return nil, fmt.Errorf("connect to %q database: %w", database, err)
+	}
+
+	return sess, nil
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-cz/skeleton/pkg/maintenance"
+	"github.com/golang-cz/skeleton/proto"
+)
+
+// TestMaintenance isn't parallel, other tests would fail during maintenance.
+func TestMaintenance(t *testing.T) {
+	ctx := context.Background()
+	root := strings.TrimSuffix(E2E.URL, "/_api")
+
+	setMaintenance := func(t *testing.T, state maintenance.State) maintenance.State {
+		t.Helper()
+
+		body, err := json.Marshal(state)
+		if err != nil {
+			t.Fatalf("encode state: %v", err)
+		}
+		req, err := http.NewRequest("PUT", root+"/_admin/maintenance", bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("create request: %v", err)
+		}
+		req.SetBasicAuth(E2E.Config.Admin.Username, E2E.Config.Admin.Password)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("set maintenance: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			b, _ := io.ReadAll(resp.Body)
+			t.Fatalf("unexpected status: %v %s", resp.StatusCode, b)
+		}
+
+		var got maintenance.State
+		if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+			t.Fatalf("decode state: %v", err)
+		}
+		return got
+	}
+
+	eta := time.Now().Add(2 * time.Minute).UTC().Truncate(time.Second)
+	state := setMaintenance(t, maintenance.State{Enabled: true, Reason: "DB migration", ETA: &eta})
+	defer setMaintenance(t, maintenance.State{Enabled: false})
+
+	if !state.Enabled || state.Reason != "DB migration" || state.ETA == nil || !state.ETA.Equal(eta) {
+		t.Fatalf("unexpected state: %+v", state)
+	}
+
+	t.Run("refused", func(t *testing.T) {
+		resp, err := http.Post(E2E.URL+"/rpc/Skeleton/GetUser", "application/json", strings.NewReader("{}"))
+		if err != nil {
+			t.Fatalf("call GetUser: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Fatalf("unexpected status: %v", resp.StatusCode)
+		}
+		if retryAfter, _ := strconv.Atoi(resp.Header.Get("Retry-After")); retryAfter <= 60 || retryAfter > 120 {
+			t.Fatalf("unexpected Retry-After: %q", resp.Header.Get("Retry-After"))
+		}
+
+		var rpcErr proto.WebRPCError
+		if err := json.NewDecoder(resp.Body).Decode(&rpcErr); err != nil {
+			t.Fatalf("decode error: %v", err)
+		}
+		if rpcErr.Code != proto.ErrMaintenance.Code || !strings.Contains(rpcErr.Cause, "DB migration") {
+			t.Fatalf("unexpected error: %+v", rpcErr)
+		}
+
+		if _, err := E2E.RPCClient.GetUser(ctx, E2E.UserId.String()); err == nil {
+			t.Fatalf("RPC client call succeeded during maintenance")
+		}
+	})
+
+	t.Run("allowed", func(t *testing.T) {
+		for _, path := range []string{"/_api/livez", "/_api/ping", "/favicon.ico"} {
+			resp, err := http.Get(root + path)
+			if err != nil {
+				t.Fatalf("get %v: %v", path, err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("unexpected status of %v: %v", path, resp.StatusCode)
+			}
+		}
+
+		req, err := http.NewRequest("GET", root+"/_admin/status", nil)
+		if err != nil {
+			t.Fatalf("create request: %v", err)
+		}
+		req.SetBasicAuth(E2E.Config.Admin.Username, E2E.Config.Admin.Password)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("get status page: %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("read status page: %v", err)
+		}
+		if !strings.Contains(string(body), "Maintenance mode") || !strings.Contains(string(body), "DB migration") {
+			t.Fatalf("status page doesn't show maintenance")
+		}
+	})
+
+	t.Run("persisted", func(t *testing.T) {
+		// New replica starts in maintenance.
+		mode, err := maintenance.New(ctx, &E2E.DB.Maintenance, E2E.Config.Maintenance)
+		if err != nil {
+			t.Fatalf("create maintenance mode: %v", err)
+		}
+		defer mode.Close()
+
+		if got := mode.State(); !got.Enabled || got.Reason != "DB migration" {
+			t.Fatalf("unexpected state of new replica: %+v", got)
+		}
+	})
+
+	setMaintenance(t, maintenance.State{Enabled: false})
+
+	if _, err := E2E.RPCClient.GetUser(ctx, E2E.UserId.String()); err != nil {
+		t.Fatalf("get user after maintenance: %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	if _, err := E2E.RPCClient.GetUser(ctx, E2E.UserId.String()); err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	// Unknown method must not create its own series.
+	resp, err := http.Post(E2E.URL+"/rpc/Skeleton/NoSuchMethod", "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("call unknown method: %v", err)
+	}
+	resp.Body.Close()
+
+	req, err := http.NewRequest("GET", strings.TrimSuffix(E2E.URL, "/_api")+"/_admin/metrics", nil)
+	if err != nil {
+		t.Fatalf("create request: %v", err)
+	}
+	req.SetBasicAuth(E2E.Config.Admin.Username, E2E.Config.Admin.Password)
+
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("get metrics: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status: %v", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read metrics: %v", err)
+	}
+	metrics := string(body)
+
+	for _, want := range []string{
+		`skeleton_http_requests_total{code="200",method="POST",route="/_api/rpc/*"}`,
+		`skeleton_rpc_requests_total{code="200",method="GetUser"}`,
+		`skeleton_rpc_errors_total{error="WebrpcBadRoute",method="unknown"}`,
+		`go_sql_open_connections{db_name="main"}`,
+		`skeleton_nats_connected`,
+	} {
+		if !strings.Contains(metrics, want) {
+			t.Errorf("metric %s is missing", want)
+		}
+	}
+
+	if strings.Contains(metrics, "NoSuchMethod") {
+		t.Errorf("unknown method is used as label")
+	}
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/golang-cz/skeleton/proto"
+)
+
+// TestRateLimit relies on "robots" rule in etc/test.toml, allowing 3
+// requests per hour.
+func TestRateLimit(t *testing.T) {
+	t.Parallel()
+
+	url := strings.TrimSuffix(E2E.URL, "/_api") + "/robots.txt"
+
+	for i := 1; i <= 3; i++ {
+		resp, err := http.Get(url)
+		if err != nil {
+			t.Fatalf("request %v: %v", i, err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("request %v: unexpected status %v", i, resp.StatusCode)
+		}
+		if got, want := resp.Header.Get("RateLimit-Remaining"), strconv.Itoa(3-i); got != want {
+			t.Fatalf("request %v: unexpected RateLimit-Remaining: got %q, want %q", i, got, want)
+		}
+		if got := resp.Header.Get("RateLimit-Policy"); got != "3;w=3600" {
+			t.Fatalf("request %v: unexpected RateLimit-Policy: %q", i, got)
+		}
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("request over limit: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("unexpected status over limit: %v", resp.StatusCode)
+	}
+	if retryAfter, _ := strconv.Atoi(resp.Header.Get("Retry-After")); retryAfter <= 0 || retryAfter > 1200 {
+		t.Fatalf("unexpected Retry-After: %q", resp.Header.Get("Retry-After"))
+	}
+
+	var rpcErr proto.WebRPCError
+	if err := json.NewDecoder(resp.Body).Decode(&rpcErr); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if rpcErr.Code != proto.ErrRateLimited.Code {
+		t.Fatalf("unexpected error: %+v", rpcErr)
+	}
+}
+
+// TestRateLimitBeforeAuth checks requests with invalid credentials are rate
+// limited too, relying on "unauthenticated" rule in etc/test.toml, allowing 2
+// requests per hour.
+func TestRateLimitBeforeAuth(t *testing.T) {
+	t.Parallel()
+
+	want := []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests}
+	for i, status := range want {
+		req, err := http.NewRequest(http.MethodGet, E2E.URL+"/ratelimit-test", nil)
+		if err != nil {
+			t.Fatalf("request %v: %v", i, err)
+		}
+		req.Header.Set("Authorization", "ApiKey skel_00000000_invalid")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("request %v: %v", i, err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != status {
+			t.Fatalf("request %v: unexpected status: got %v, want %v", i, resp.StatusCode, status)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+
+	"github.com/golang-cz/skeleton/internal/guuid"
+	"github.com/golang-cz/skeleton/pkg/rbac"
+	"github.com/golang-cz/skeleton/proto"
+	"github.com/golang-cz/skeleton/proto/client/skeleton"
+)
+
+func TestRBAC(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	user, err := E2E.RPCClient.CreateUser(ctx, newUserInput("Ozzy", "Osbourne"))
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	client := rbacClient(t, user.ID, E2E.ApplicationId)
+
+	roles, permissions, err := client.GetMyPermissions(ctx)
+	if err != nil {
+		t.Fatalf("get my permissions: %v", err)
+	}
+	if len(roles) != 0 || len(permissions) != 0 {
+		t.Fatalf("unexpected grants: roles %v, permissions %v", roles, permissions)
+	}
+
+	_, err = client.GetUser(ctx, user.ID.String())
+	assertRPCError(t, err, proto.ErrPermissionDenied.Code)
+
+	if err := E2E.RPCClient.AssignRole(ctx, user.ID.String(), "viewer"); err != nil {
+		t.Fatalf("assign role: %v", err)
+	}
+
+	// Assignment invalidates cached permissions.
+	if _, err := client.GetUser(ctx, user.ID.String()); err != nil {
+		t.Fatalf("get user as viewer: %v", err)
+	}
+	err = client.DeleteUser(ctx, user.ID.String())
+	assertRPCError(t, err, proto.ErrPermissionDenied.Code)
+	err = client.AssignRole(ctx, user.ID.String(), "admin")
+	assertRPCError(t, err, proto.ErrPermissionDenied.Code)
+
+	roles, permissions, err = client.GetMyPermissions(ctx)
+	if err != nil {
+		t.Fatalf("get my permissions: %v", err)
+	}
+	if !slices.Equal(roles, []string{"viewer"}) || !slices.Equal(permissions, []string{"users:read"}) {
+		t.Fatalf("unexpected grants: roles %v, permissions %v", roles, permissions)
+	}
+
+	// Roles are scoped per application.
+	_, err = rbacClient(t, user.ID, guuid.NewV7()).GetUser(ctx, user.ID.String())
+	assertRPCError(t, err, proto.ErrPermissionDenied.Code)
+	_, err = rbacClient(t, user.ID, uuid.Nil).GetUser(ctx, user.ID.String())
+	assertRPCError(t, err, proto.ErrPermissionDenied.Code)
+
+	if err := E2E.RPCClient.UnassignRole(ctx, user.ID.String(), "viewer"); err != nil {
+		t.Fatalf("unassign role: %v", err)
+	}
+	_, err = client.GetUser(ctx, user.ID.String())
+	assertRPCError(t, err, proto.ErrPermissionDenied.Code)
+
+	t.Run("invalid", func(t *testing.T) {
+		err := E2E.RPCClient.AssignRole(ctx, user.ID.String(), "superuser")
+		assertRPCError(t, err, proto.ErrInvalidArgument.Code)
+
+		err = E2E.RPCClient.AssignRole(ctx, guuid.NewV7().String(), "viewer")
+		assertRPCError(t, err, proto.ErrInvalidArgument.Code)
+
+		err = E2E.RPCClient.UnassignRole(ctx, user.ID.String(), "viewer")
+		assertRPCError(t, err, proto.ErrNotFound.Code)
+
+		_, err = E2E.RPCClientWithToken("").GetUser(ctx, user.ID.String())
+		assertRPCError(t, err, proto.ErrUnauthenticated.Code)
+	})
+}
+
+func TestRBACInvalidation(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	user, err := E2E.RPCClient.CreateUser(ctx, newUserInput("Tony", "Iommi"))
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	// Cache of another replica.
+	replica := rbac.New(E2E.DB.Role, E2E.Config.RBAC)
+
+	grants, err := replica.Grants(ctx, user.ID, E2E.ApplicationId)
+	if err != nil {
+		t.Fatalf("get grants: %v", err)
+	}
+	if len(grants.Roles) != 0 {
+		t.Fatalf("unexpected roles: %v", grants.Roles)
+	}
+
+	if err := E2E.RPCClient.AssignRole(ctx, user.ID.String(), "editor"); err != nil {
+		t.Fatalf("assign role: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		grants, err := replica.Grants(ctx, user.ID, E2E.ApplicationId)
+		if err != nil {
+			t.Fatalf("get grants: %v", err)
+		}
+		if slices.Equal(grants.Roles, []string{"editor"}) && grants.Has("users:write") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("cached grants weren't invalidated: %+v", grants)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// rbacClient returns RPC client authenticated as the user in the application.
+func rbacClient(t *testing.T, userId, applicationId uuid.UUID) skeleton.Skeleton {
+	t.Helper()
+
+	token, err := E2E.ApplicationToken(userId, applicationId, time.Hour)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+
+	return E2E.RPCClientWithToken(token)
+}
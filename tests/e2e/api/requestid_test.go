@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/golang-cz/skeleton/internal/guuid"
+	"github.com/golang-cz/skeleton/internal/reqctx"
+	"github.com/golang-cz/skeleton/proto"
+)
+
+func TestRequestId(t *testing.T) {
+	t.Parallel()
+
+	clientId := "e2e-" + guuid.NewV7().String()
+
+	tt := []struct {
+		name      string
+		requestId string
+		keep      bool
+	}{
+		{name: "client id", requestId: clientId, keep: true},
+		{name: "no id", requestId: ""},
+		{name: "invalid id", requestId: "*/ DROP TABLE users; /*"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			// Invalid user id, so the response is an error.
+			req, err := http.NewRequest("POST", E2E.URL+"/rpc/Skeleton/GetUser", strings.NewReader(`{"id": "not-uuid"}`))
+			if err != nil {
+				t.Fatalf("create request: %v", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			if tc.requestId != "" {
+				req.Header.Set(reqctx.RequestIdHeader, tc.requestId)
+			}
+
+			resp, err := E2E.Client.Do(req)
+			if err != nil {
+				t.Fatalf("send request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			requestId := resp.Header.Get(reqctx.RequestIdHeader)
+			if tc.keep && requestId != tc.requestId {
+				t.Fatalf("unexpected request id: got %q, want %q", requestId, tc.requestId)
+			}
+			if !tc.keep && (requestId == tc.requestId || !reqctx.ValidRequestId(requestId)) {
+				t.Fatalf("unexpected request id: got %q", requestId)
+			}
+
+			var rpcErr proto.WebRPCError
+			if err := json.NewDecoder(resp.Body).Decode(&rpcErr); err != nil {
+				t.Fatalf("decode error: %v", err)
+			}
+			if !strings.Contains(rpcErr.Cause, "requestId="+requestId) {
+				t.Fatalf("request id missing in error cause: %q", rpcErr.Cause)
+			}
+		})
+	}
+}
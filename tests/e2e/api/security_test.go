@@ -0,0 +1,121 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	t.Parallel()
+
+	resp, err := http.Get(strings.TrimSuffix(E2E.URL, "/_api") + "/favicon.ico")
+	if err != nil {
+		t.Fatalf("get favicon.ico: %v", err)
+	}
+	resp.Body.Close()
+
+	for header, want := range map[string]string{
+		"Strict-Transport-Security": "max-age=31536000; includeSubDomains",
+		"X-Content-Type-Options":    "nosniff",
+		"X-Frame-Options":           "DENY",
+		"Referrer-Policy":           "strict-origin-when-cross-origin",
+		"Content-Security-Policy":   E2E.Config.HTTP.Security.ContentSecurityPolicy,
+		"Permissions-Policy":        E2E.Config.HTTP.Security.PermissionsPolicy,
+	} {
+		if got := resp.Header.Get(header); got != want {
+			t.Errorf("%v: got %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestCORS(t *testing.T) {
+	t.Parallel()
+
+	root := strings.TrimSuffix(E2E.URL, "/_api")
+
+	preflight := func(t *testing.T, path, origin, method, headers string) *http.Response {
+		t.Helper()
+
+		req, err := http.NewRequest("OPTIONS", root+path, nil)
+		if err != nil {
+			t.Fatalf("create request: %v", err)
+		}
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", method)
+		if headers != "" {
+			req.Header.Set("Access-Control-Request-Headers", headers)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("send preflight: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	t.Run("rpc", func(t *testing.T) {
+		for origin, allowed := range map[string]bool{
+			"https://app.example.com":        true,
+			"https://shop.example.org":       true,
+			"https://a.b.example.org":        true,
+			"https://example.org":            false,
+			"https://evil.com":               false,
+			"https://app.example.com.evil.x": false,
+		} {
+			resp := preflight(t, "/_api/rpc/Skeleton/GetUser", origin, "POST", "Content-Type, Idempotency-Key, X-Request-Id")
+			got := resp.Header.Get("Access-Control-Allow-Origin")
+			if allowed && got != origin {
+				t.Errorf("origin %v is not allowed: %q", origin, got)
+			}
+			if !allowed && got != "" {
+				t.Errorf("origin %v is allowed: %q", origin, got)
+			}
+			if allowed && resp.Header.Get("Access-Control-Allow-Credentials") != "true" {
+				t.Errorf("origin %v: credentials are not allowed", origin)
+			}
+		}
+
+		resp := preflight(t, "/_api/rpc/Skeleton/GetUser", "https://app.example.com", "POST", "X-Custom")
+		if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("header X-Custom is allowed")
+		}
+	})
+
+	t.Run("exposed headers", func(t *testing.T) {
+		req, err := http.NewRequest("POST", E2E.URL+"/rpc/Skeleton/NoSuchMethod", strings.NewReader("{}"))
+		if err != nil {
+			t.Fatalf("create request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Origin", "https://shop.example.org")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("send request: %v", err)
+		}
+		resp.Body.Close()
+
+		if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "https://shop.example.org" {
+			t.Errorf("unexpected allowed origin: %q", got)
+		}
+		if got := resp.Header.Get("Access-Control-Expose-Headers"); !strings.Contains(got, "X-Request-Id") {
+			t.Errorf("X-Request-Id is not exposed: %q", got)
+		}
+	})
+
+	t.Run("status", func(t *testing.T) {
+		resp := preflight(t, "/_api/readyz", "https://anything.dev", "GET", "")
+		if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "*" {
+			t.Errorf("unexpected allowed origin: %q", got)
+		}
+	})
+
+	t.Run("admin", func(t *testing.T) {
+		resp := preflight(t, "/_admin/build-info", "https://app.example.com", "GET", "")
+		if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("admin allows cross-origin requests from %q", got)
+		}
+	})
+}
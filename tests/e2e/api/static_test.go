@@ -0,0 +1,126 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/golang-cz/skeleton/config"
+	"github.com/golang-cz/skeleton/pkg/static"
+)
+
+func TestStatic(t *testing.T) {
+	t.Parallel()
+
+	root := strings.TrimSuffix(E2E.URL, "/_api")
+
+	get := func(t *testing.T, path string, header http.Header) (*http.Response, string) {
+		t.Helper()
+
+		req, err := http.NewRequest("GET", root+path, nil)
+		if err != nil {
+			t.Fatalf("create request: %v", err)
+		}
+		for key := range header {
+			req.Header.Set(key, header.Get(key))
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("get %v: %v", path, err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("read %v: %v", path, err)
+		}
+		return resp, string(body)
+	}
+
+	resp, index := get(t, "/", nil)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(index, `<div id="app">`) {
+		t.Fatalf("unexpected index: %v %.100q", resp.StatusCode, index)
+	}
+	etag := resp.Header.Get("ETag")
+	if etag == "" || resp.Header.Get("Cache-Control") != "no-cache" {
+		t.Fatalf("unexpected cache headers: %v", resp.Header)
+	}
+
+	t.Run("not modified", func(t *testing.T) {
+		resp, _ := get(t, "/", http.Header{"If-None-Match": {etag}})
+		if resp.StatusCode != http.StatusNotModified {
+			t.Fatalf("unexpected status: %v", resp.StatusCode)
+		}
+	})
+
+	t.Run("SPA fallback", func(t *testing.T) {
+		resp, body := get(t, "/users/123/edit", nil)
+		if resp.StatusCode != http.StatusOK || body != index {
+			t.Fatalf("unexpected response: %v %.100q", resp.StatusCode, body)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		for _, path := range []string{"/assets/missing.js", "/_unknown"} {
+			if resp, _ := get(t, path, nil); resp.StatusCode != http.StatusNotFound {
+				t.Fatalf("unexpected status of %v: %v", path, resp.StatusCode)
+			}
+		}
+	})
+
+	t.Run("favicon", func(t *testing.T) {
+		resp, body := get(t, "/favicon.ico", nil)
+		if resp.StatusCode != http.StatusOK || len(body) == 0 || resp.Header.Get("Content-Type") != "image/vnd.microsoft.icon" {
+			t.Fatalf("unexpected favicon: %v %v %v", resp.StatusCode, len(body), resp.Header.Get("Content-Type"))
+		}
+	})
+
+	t.Run("hashed and precompressed assets", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"index.html":                {Data: []byte("<html></html>")},
+			"assets/app-1f2e3d4c.js":    {Data: []byte("console.log('app')")},
+			"assets/app-1f2e3d4c.js.br": {Data: []byte("brotli")},
+			"assets/app-1f2e3d4c.js.gz": {Data: []byte("gzip")},
+		}
+		srv, err := static.New(fsys, config.Static{MountPath: "/admin/", ImmutablePaths: []string{"/assets/"}})
+		if err != nil {
+			t.Fatalf("create static server: %v", err)
+		}
+		defer srv.Close()
+
+		for acceptEncoding, want := range map[string]string{
+			"gzip, br": "brotli",
+			"gzip":     "gzip",
+			"zstd":     "console.log('app')",
+			"":         "console.log('app')",
+		} {
+			req := httptest.NewRequest("GET", "/admin/assets/app-1f2e3d4c.js", nil)
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+
+			if rec.Body.String() != want {
+				t.Errorf("Accept-Encoding %q: got %q, want %q", acceptEncoding, rec.Body.String(), want)
+			}
+			if got := rec.Header().Get("Cache-Control"); got != "public, max-age=31536000, immutable" {
+				t.Errorf("unexpected Cache-Control: %q", got)
+			}
+			if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/javascript") {
+				t.Errorf("unexpected Content-Type: %q", got)
+			}
+			if rec.Header().Get("Vary") != "Accept-Encoding" {
+				t.Errorf("unexpected Vary: %q", rec.Header().Get("Vary"))
+			}
+		}
+
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest("GET", "/admin", nil))
+		if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "/admin/" {
+			t.Fatalf("unexpected redirect: %v %q", rec.Code, rec.Header().Get("Location"))
+		}
+	})
+}
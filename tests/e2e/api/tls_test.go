@@ -0,0 +1,255 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/http2"
+
+	"github.com/golang-cz/skeleton/config"
+	"github.com/golang-cz/skeleton/internal/reqctx"
+	"github.com/golang-cz/skeleton/pkg/tlsconfig"
+)
+
+func TestTLS(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	serverCA := newTestCert(t, "Server CA", nil)
+	clientCA := newTestCert(t, "Client CA", nil)
+	otherCA := newTestCert(t, "Other CA", nil)
+
+	serverCert := newTestCert(t, "server-1", serverCA)
+	serverCert.write(t, filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"))
+	clientCA.write(t, filepath.Join(dir, "client-ca.crt"), "")
+
+	reloader, err := tlsconfig.New(config.TLS{
+		Enabled:        true,
+		CertFile:       filepath.Join(dir, "server.crt"),
+		KeyFile:        filepath.Join(dir, "server.key"),
+		MinVersion:     "1.2",
+		ClientCAFile:   filepath.Join(dir, "client-ca.crt"),
+		ClientAuth:     "verify_if_given",
+		ReloadInterval: config.Duration(20 * time.Millisecond),
+	})
+	if err != nil {
+		t.Fatalf("create TLS config: %v", err)
+	}
+	defer reloader.Close()
+
+	// Echoes the client certificate identity.
+	handler := E2E.API.REST.ClientCert(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if clientCert := reqctx.GetClientCert(r.Context()); clientCert != nil {
+			fmt.Fprintf(w, "%s %s", clientCert.CommonName, strings.Join(clientCert.URIs, ","))
+		}
+	}))
+
+	srv := &http.Server{Handler: handler, TLSConfig: reloader.Config()}
+	if err := http2.ConfigureServer(srv, &http2.Server{}); err != nil {
+		t.Fatalf("configure HTTP/2: %v", err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go srv.ServeTLS(ln, "", "")
+	defer srv.Close()
+
+	baseURL := "https://" + ln.Addr().String()
+
+	get := func(t *testing.T, clientCert *testCert) (*http.Response, string, error) {
+		t.Helper()
+
+		tlsConf := &tls.Config{RootCAs: x509.NewCertPool()}
+		tlsConf.RootCAs.AddCert(serverCA.cert)
+		if clientCert != nil {
+			tlsConf.Certificates = []tls.Certificate{clientCert.tlsCertificate()}
+		}
+		client := &http.Client{
+			Timeout:   5 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: tlsConf, ForceAttemptHTTP2: true},
+		}
+
+		resp, err := client.Get(baseURL)
+		if err != nil {
+			return nil, "", err
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		return resp, string(body), err
+	}
+
+	t.Run("HTTP/2 with client certificate", func(t *testing.T) {
+		resp, body, err := get(t, newTestCert(t, "billing-service", clientCA))
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if resp.Proto != "HTTP/2.0" {
+			t.Errorf("unexpected protocol: %v", resp.Proto)
+		}
+		if body != "billing-service spiffe://skeleton/billing-service" {
+			t.Errorf("unexpected client identity: %q", body)
+		}
+	})
+
+	t.Run("without client certificate", func(t *testing.T) {
+		_, body, err := get(t, nil)
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if body != "" {
+			t.Errorf("unexpected client identity: %q", body)
+		}
+	})
+
+	t.Run("client certificate of unknown CA", func(t *testing.T) {
+		_, body, err := get(t, newTestCert(t, "intruder", otherCA))
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if body != "" {
+			t.Errorf("untrusted client identity: %q", body)
+		}
+	})
+
+	t.Run("certificate reload", func(t *testing.T) {
+		renewed := newTestCert(t, "server-2", serverCA)
+		renewed.write(t, filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"))
+
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			resp, _, err := get(t, nil)
+			if err == nil && resp.TLS.PeerCertificates[0].Subject.CommonName == "server-2" {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("renewed certificate wasn't served: %v", err)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	})
+}
+
+func TestH2C(t *testing.T) {
+	t.Parallel()
+
+	if !E2E.Config.HTTP.H2C {
+		t.Skip("h2c is disabled")
+	}
+
+	// HTTP/2 with prior knowledge over plain TCP.
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+				return net.Dial(network, addr)
+			},
+		},
+	}
+
+	resp, err := client.Get(E2E.URL + "/ping")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || resp.Proto != "HTTP/2.0" {
+		t.Fatalf("unexpected response: %v %v", resp.Proto, resp.Status)
+	}
+}
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// newTestCert creates CA certificate, or certificate issued by ca. Leaf
+// certificates are valid for localhost and carry SPIFFE id.
+func newTestCert(t *testing.T, commonName string, ca *testCert) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatalf("generate serial: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{"Skeleton"}},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	parent, parentKey := template, key
+	if ca == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		parent, parentKey = ca.cert, ca.key
+		template.KeyUsage = x509.KeyUsageDigitalSignature
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+		template.DNSNames = []string{"localhost"}
+		template.IPAddresses = []net.IP{net.IPv4(127, 0, 0, 1)}
+		template.URIs = []*url.URL{{Scheme: "spiffe", Host: "skeleton", Path: "/" + commonName}}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+
+	return &testCert{cert: cert, key: key}
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key, Leaf: c.cert}
+}
+
+// write saves the certificate and key, if keyFile isn't empty, in PEM.
+func (c *testCert) write(t *testing.T, certFile, keyFile string) {
+	t.Helper()
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw})
+	if err := os.WriteFile(certFile, certPEM, 0o600); err != nil {
+		t.Fatalf("write certificate: %v", err)
+	}
+	if keyFile == "" {
+		return
+	}
+
+	der, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestTracing replaces the global tracer provider, so it must not run in
+// parallel with other tests.
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	prevProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(prevProvider) })
+
+	traceId := "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	req, err := http.NewRequest("POST", E2E.URL+"/rpc/Skeleton/GetUser", strings.NewReader(fmt.Sprintf(`{"id": %q}`, E2E.UserId)))
+	if err != nil {
+		t.Fatalf("create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("traceparent", "00-"+traceId+"-00f067aa0ba902b7-01")
+
+	resp, err := E2E.Client.Do(req)
+	if err != nil {
+		t.Fatalf("send request: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status: %v", resp.StatusCode)
+	}
+
+	want := map[string]bool{
+		"POST /_api/rpc/*": false,
+		"Skeleton/GetUser": false,
+		"SELECT":           false,
+	}
+
+	// Server span ends after the response is sent.
+	deadline := time.Now().Add(time.Second)
+	for {
+		for _, span := range recorder.Ended() {
+			if span.SpanContext().TraceID().String() != traceId {
+				continue
+			}
+			if _, ok := want[span.Name()]; ok {
+				want[span.Name()] = true
+			}
+		}
+
+		missing := []string{}
+		for name, found := range want {
+			if !found {
+				missing = append(missing, name)
+			}
+		}
+		if len(missing) == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("spans missing in the trace: %v", missing)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
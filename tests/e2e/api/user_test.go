@@ -3,20 +3,25 @@ package api
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
-
-	"github.com/gofrs/uuid/v5"
+	"time"
 
 	"github.com/golang-cz/skeleton/data"
+	"github.com/golang-cz/skeleton/internal/guuid"
 	"github.com/golang-cz/skeleton/proto"
+	"github.com/golang-cz/skeleton/proto/client/skeleton"
 )
 
 func TestUser(t *testing.T) {
-	id := uuid.Must(uuid.FromString("018c1b46-78c4-7484-b677-eb177d0b8f61"))
+	t.Parallel()
+
+	// Fresh id and email on every run, so the test doesn't collide with itself.
+	id := guuid.NewV7()
 	user := &data.User{
 		User: &proto.User{
 			ID:        id,
-			Email:     "jimmy.page@yardbirds.com",
+			Email:     fmt.Sprintf("jimmy.page+%s@yardbirds.com", id),
 			Firstname: "Jimmy",
 			Lastname:  "Page",
 		},
@@ -30,5 +35,328 @@ func TestUser(t *testing.T) {
 		t.Fatalf("load user from RPC: %v", err)
 	}
 
-	fmt.Println(userOut)
+	if userOut.ID != id || userOut.Email != user.Email {
+		t.Fatalf("unexpected user: got %+v, want %+v", userOut, user.User)
+	}
+}
+
+func TestUserEncryptedEmailLookup(t *testing.T) {
+	t.Parallel()
+
+	tx := E2E.TxDB(t)
+
+	id := guuid.NewV7()
+	user := &data.User{
+		User: &proto.User{
+			ID:        id,
+			Email:     fmt.Sprintf("Bob.Ross+%s@happy-little-accident.com", id),
+			Firstname: "Bob",
+			Lastname:  "Ross",
+		},
+	}
+	if err := tx.Save(user); err != nil {
+		t.Fatalf("save user to DB: %v", err)
+	}
+
+	// Lookup is case insensitive, the blind index is computed from normalized email.
+	userOut, err := tx.User.FindByEmail(strings.ToLower(user.Email))
+	if err != nil {
+		t.Fatalf("find user by email: %v", err)
+	}
+
+	if userOut.ID != id || userOut.Email != user.Email || userOut.Firstname != "Bob" {
+		t.Fatalf("unexpected user: got %+v, want %+v", userOut.User, user.User)
+	}
+
+	var raw struct {
+		Email []byte `db:"email"`
+	}
+	if err := tx.SQL().Select("email").From("users").Where("id", id).One(&raw); err != nil {
+		t.Fatalf("select raw email: %v", err)
+	}
+	if strings.Contains(string(raw.Email), "happy-little-accident") {
+		t.Fatalf("email stored in plaintext")
+	}
+}
+
+// newUserInput returns input with email unique to the test run.
+func newUserInput(firstname, lastname string) *skeleton.UserInput {
+	return &skeleton.UserInput{
+		Email:     fmt.Sprintf("%s.%s+%s@golang.cz", strings.ToLower(firstname), strings.ToLower(lastname), guuid.NewV7()),
+		Firstname: firstname,
+		Lastname:  lastname,
+	}
+}
+
+func TestCreateUser(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	input := newUserInput("Robert", "Plant")
+	user, err := E2E.RPCClient.CreateUser(ctx, input)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if user.ID.IsNil() || user.Email != input.Email || user.Firstname != "Robert" || user.CreatedAt.IsZero() || user.DeletedAt != nil {
+		t.Fatalf("unexpected user: %+v", user)
+	}
+
+	userOut, err := E2E.RPCClient.GetUser(ctx, user.ID.String())
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	if userOut.Email != input.Email || userOut.Lastname != "Plant" {
+		t.Fatalf("unexpected user: %+v", userOut)
+	}
+
+	t.Run("email taken", func(t *testing.T) {
+		taken := newUserInput("Bobby", "Plant")
+		taken.Email = strings.ToUpper(input.Email)
+		_, err := E2E.RPCClient.CreateUser(ctx, taken)
+		assertRPCError(t, err, proto.ErrConflict.Code)
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		for _, input := range []*skeleton.UserInput{
+			{Firstname: "No", Lastname: "Email"},
+			{Email: "not an email", Firstname: "Bad", Lastname: "Email"},
+			{Email: "Jimmy <jimmy@golang.cz>", Firstname: "Display", Lastname: "Name"},
+			{Email: "long@golang.cz", Firstname: strings.Repeat("x", 256)},
+		} {
+			_, err := E2E.RPCClient.CreateUser(ctx, input)
+			assertRPCError(t, err, proto.ErrInvalidArgument.Code)
+		}
+	})
+}
+
+func TestUpdateUser(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	user, err := E2E.RPCClient.CreateUser(ctx, newUserInput("John", "Bonham"))
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	// Only fields in the mask are updated.
+	changes := newUserInput("Jason", "Bonham")
+	updated, err := E2E.RPCClient.UpdateUser(ctx, user.ID.String(), changes, []string{"firstname"})
+	if err != nil {
+		t.Fatalf("update user: %v", err)
+	}
+	if updated.Firstname != "Jason" || updated.Email != user.Email || !updated.UpdatedAt.After(user.UpdatedAt) {
+		t.Fatalf("unexpected user: %+v", updated)
+	}
+
+	updated, err = E2E.RPCClient.UpdateUser(ctx, user.ID.String(), changes, []string{"email", "lastname"})
+	if err != nil {
+		t.Fatalf("update user: %v", err)
+	}
+	userOut, err := E2E.RPCClient.GetUser(ctx, user.ID.String())
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	if userOut.Email != changes.Email || userOut.Firstname != "Jason" || userOut.Lastname != "Bonham" {
+		t.Fatalf("unexpected user: %+v", userOut)
+	}
+
+	tt := []struct {
+		name      string
+		id        string
+		input     *skeleton.UserInput
+		fieldMask []string
+		code      int
+	}{
+		{name: "empty field mask", id: user.ID.String(), input: changes, code: proto.ErrInvalidArgument.Code},
+		{name: "unknown field", id: user.ID.String(), input: changes, fieldMask: []string{"id"}, code: proto.ErrInvalidArgument.Code},
+		{name: "invalid email", id: user.ID.String(), input: &skeleton.UserInput{}, fieldMask: []string{"email"}, code: proto.ErrInvalidArgument.Code},
+		{name: "email taken", id: user.ID.String(), input: &skeleton.UserInput{Email: E2E.User.Email}, fieldMask: []string{"email"}, code: proto.ErrConflict.Code},
+		{name: "malformed id", id: "42", input: changes, fieldMask: []string{"email"}, code: proto.ErrInvalidArgument.Code},
+		{name: "not found", id: guuid.NewV7().String(), input: changes, fieldMask: []string{"email"}, code: proto.ErrNotFound.Code},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := E2E.RPCClient.UpdateUser(ctx, tc.id, tc.input, tc.fieldMask)
+			assertRPCError(t, err, tc.code)
+		})
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	input := newUserInput("John Paul", "Jones")
+	user, err := E2E.RPCClient.CreateUser(ctx, input)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	if err := E2E.RPCClient.DeleteUser(ctx, user.ID.String()); err != nil {
+		t.Fatalf("delete user: %v", err)
+	}
+
+	_, err = E2E.RPCClient.GetUser(ctx, user.ID.String())
+	assertRPCError(t, err, proto.ErrNotFound.Code)
+
+	// Soft deleted, the user is kept.
+	users, _, err := E2E.RPCClient.ListUsers(ctx, &skeleton.UsersFilter{Email: input.Email, Status: "deleted"}, "", "", 0)
+	if err != nil {
+		t.Fatalf("list users: %v", err)
+	}
+	if len(users) != 1 || users[0].ID != user.ID || users[0].DeletedAt == nil {
+		t.Fatalf("deleted user is not kept: %+v", users)
+	}
+
+	err = E2E.RPCClient.DeleteUser(ctx, user.ID.String())
+	assertRPCError(t, err, proto.ErrNotFound.Code)
+
+	_, err = E2E.RPCClient.UpdateUser(ctx, user.ID.String(), input, []string{"firstname"})
+	assertRPCError(t, err, proto.ErrNotFound.Code)
+
+	err = E2E.RPCClient.DeleteUser(ctx, "42")
+	assertRPCError(t, err, proto.ErrInvalidArgument.Code)
+}
+
+func TestRestoreUser(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	input := newUserInput("Peter", "Grant")
+	user, err := E2E.RPCClient.CreateUser(ctx, input)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	_, err = E2E.RPCClient.RestoreUser(ctx, user.ID.String())
+	assertRPCError(t, err, proto.ErrNotFound.Code)
+
+	if err := E2E.RPCClient.DeleteUser(ctx, user.ID.String()); err != nil {
+		t.Fatalf("delete user: %v", err)
+	}
+
+	// Email of deleted user can be used again, the user can't be restored then.
+	other, err := E2E.RPCClient.CreateUser(ctx, input)
+	if err != nil {
+		t.Fatalf("create user with email of deleted user: %v", err)
+	}
+	_, err = E2E.RPCClient.RestoreUser(ctx, user.ID.String())
+	assertRPCError(t, err, proto.ErrConflict.Code)
+
+	if err := E2E.RPCClient.DeleteUser(ctx, other.ID.String()); err != nil {
+		t.Fatalf("delete user: %v", err)
+	}
+
+	restored, err := E2E.RPCClient.RestoreUser(ctx, user.ID.String())
+	if err != nil {
+		t.Fatalf("restore user: %v", err)
+	}
+	if restored.ID != user.ID || restored.DeletedAt != nil || restored.Email != input.Email {
+		t.Fatalf("unexpected user: %+v", restored)
+	}
+}
+
+// TestListUsers isn't parallel, so no other test creates users meanwhile.
+func TestListUsers(t *testing.T) {
+	ctx := context.Background()
+
+	createdAfter := time.Now()
+
+	var ids []string
+	for i := 0; i < 5; i++ {
+		user, err := E2E.RPCClient.CreateUser(ctx, newUserInput("Listed", fmt.Sprintf("User%d", i)))
+		if err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+		ids = append(ids, user.ID.String())
+	}
+	if err := E2E.RPCClient.DeleteUser(ctx, ids[4]); err != nil {
+		t.Fatalf("delete user: %v", err)
+	}
+
+	// list returns ids of all users, fetched in pages of two.
+	list := func(t *testing.T, filter *skeleton.UsersFilter, sort string) []string {
+		t.Helper()
+
+		filter.CreatedAfter = &createdAfter
+
+		var listed []string
+		cursor := ""
+		for page := 0; ; page++ {
+			users, nextCursor, err := E2E.RPCClient.ListUsers(ctx, filter, sort, cursor, 2)
+			if err != nil {
+				t.Fatalf("list users: %v", err)
+			}
+			if len(users) > 2 || page > 5 {
+				t.Fatalf("unexpected page %d of %d users", page, len(users))
+			}
+			for _, user := range users {
+				listed = append(listed, user.ID.String())
+			}
+			if nextCursor == "" {
+				return listed
+			}
+			cursor = nextCursor
+		}
+	}
+
+	tt := []struct {
+		name   string
+		filter *skeleton.UsersFilter
+		sort   string
+		want   []string
+	}{
+		{name: "active", filter: &skeleton.UsersFilter{}, want: ids[:4]},
+		{name: "descending", filter: &skeleton.UsersFilter{}, sort: "-createdAt", want: []string{ids[3], ids[2], ids[1], ids[0]}},
+		{name: "updated", filter: &skeleton.UsersFilter{Status: "all"}, sort: "-updatedAt", want: []string{ids[4], ids[3], ids[2], ids[1], ids[0]}},
+		{name: "deleted", filter: &skeleton.UsersFilter{Status: "deleted"}, want: ids[4:]},
+		{name: "all", filter: &skeleton.UsersFilter{Status: "all"}, sort: "createdAt", want: ids},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := list(t, tc.filter, tc.sort); strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Fatalf("unexpected users:\ngot  %v\nwant %v", got, tc.want)
+			}
+		})
+	}
+
+	t.Run("email", func(t *testing.T) {
+		user, err := E2E.RPCClient.GetUser(ctx, ids[2])
+		if err != nil {
+			t.Fatalf("get user: %v", err)
+		}
+
+		users, nextCursor, err := E2E.RPCClient.ListUsers(ctx, &skeleton.UsersFilter{Email: strings.ToUpper(user.Email)}, "", "", 0)
+		if err != nil {
+			t.Fatalf("list users: %v", err)
+		}
+		if len(users) != 1 || users[0].ID != user.ID || nextCursor != "" {
+			t.Fatalf("unexpected users: %+v", users)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, cursor, err := E2E.RPCClient.ListUsers(ctx, &skeleton.UsersFilter{CreatedAfter: &createdAfter}, "createdAt", "", 1)
+		if err != nil || cursor == "" {
+			t.Fatalf("list users: %v", err)
+		}
+
+		for _, args := range []struct {
+			filter *skeleton.UsersFilter
+			sort   string
+			cursor string
+			limit  int
+		}{
+			{filter: &skeleton.UsersFilter{}, sort: "email"},
+			{filter: &skeleton.UsersFilter{Status: "banned"}},
+			{filter: &skeleton.UsersFilter{}, limit: 1000},
+			{filter: &skeleton.UsersFilter{}, cursor: "not-a-cursor"},
+			{filter: &skeleton.UsersFilter{}, sort: "-createdAt", cursor: cursor},
+		} {
+			_, _, err := E2E.RPCClient.ListUsers(ctx, args.filter, args.sort, args.cursor, args.limit)
+			assertRPCError(t, err, proto.ErrInvalidArgument.Code)
+		}
+	})
 }
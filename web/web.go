@@ -0,0 +1,20 @@
+// Package web embeds the frontend built into web/dist, so the API binary
+// serves it, see pkg/static.
+package web
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed all:dist
+var dist embed.FS
+
+// Dist returns the built frontend.
+func Dist() fs.FS {
+	sub, err := fs.Sub(dist, "dist")
+	if err != nil {
+		panic(err) // The directory is embedded, it can't be missing.
+	}
+	return sub
+}